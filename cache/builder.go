@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// Builder collects the settings of a [Cache], created through [New]. Invalid settings are reported by [Builder.Build].
+type Builder[K, V comparable] struct {
+	capacity        uint64
+	hasher          hash.Hasher[K]
+	ttl             time.Duration
+	weigher         maps.Weigher[K, V]
+	max_weight      uint64
+	loader          maps.Loader[K, V]
+	loading_options []options.Option[maps.LoadingOptions]
+	map_options     []options.Option[maps.Options]
+	clock           func() time.Time
+	errs            []error
+}
+
+// New creates a new Builder, for a cache sized for 1024 items that never expires or evicts by default.
+func New[K, V comparable]() *Builder[K, V] {
+	return &Builder[K, V]{
+		capacity:        1024,
+		hasher:          nil,
+		ttl:             0,
+		weigher:         nil,
+		max_weight:      0,
+		loader:          nil,
+		loading_options: nil,
+		map_options:     nil,
+		clock:           time.Now,
+		errs:            nil,
+	}
+}
+
+// WithCapacity sets the amount of items the cache is initially sized for, it grows when exceeded
+func (b *Builder[K, V]) WithCapacity(capacity uint64) *Builder[K, V] {
+	if capacity == 0 {
+		b.errs = append(b.errs, errors.New("capacity has to be larger than 0"))
+	}
+
+	b.capacity = capacity
+	return b
+}
+
+// WithHasher sets the hasher of the keys, [hash.ComparableHasher] is used by default
+func (b *Builder[K, V]) WithHasher(hasher hash.Hasher[K]) *Builder[K, V] {
+	if hasher == nil {
+		b.errs = append(b.errs, errors.New("hasher is nil"))
+	}
+
+	b.hasher = hasher
+	return b
+}
+
+// WithTTL sets how long a value lives after it was set or loaded, 0 keeps values until they are deleted or evicted
+func (b *Builder[K, V]) WithTTL(ttl time.Duration) *Builder[K, V] {
+	if ttl < 0 {
+		b.errs = append(b.errs, errors.New("ttl can not be negative"))
+	}
+
+	b.ttl = ttl
+	return b
+}
+
+// WithEviction bounds the total weight of the values, once exceeded items are evicted from the oldest bucket of the chain in slot order,
+// which is not the order they were added in.
+// A nil weigher counts every item as 1, bounding the amount of items to max_weight
+func (b *Builder[K, V]) WithEviction(weigher maps.Weigher[K, V], max_weight uint64) *Builder[K, V] {
+	if max_weight == 0 {
+		b.errs = append(b.errs, errors.New("max weight has to be larger than 0"))
+	}
+	if weigher == nil {
+		weigher = maps.WeigherFunc[K, V](func(key K, value V) uint64 { return 1 })
+	}
+
+	b.weigher = weigher
+	b.max_weight = max_weight
+	return b
+}
+
+// WithLoader loads the values that are missing or expired on get through the loader, the options configure refreshing, see [maps.Loading]
+func (b *Builder[K, V]) WithLoader(loader maps.Loader[K, V], opts ...options.Option[maps.LoadingOptions]) *Builder[K, V] {
+	if loader == nil {
+		b.errs = append(b.errs, errors.New("loader is nil"))
+	}
+
+	b.loader = loader
+	b.loading_options = append(b.loading_options, opts...)
+	return b
+}
+
+// WithClock sets the function used to get the current time
+func (b *Builder[K, V]) WithClock(clock func() time.Time) *Builder[K, V] {
+	if clock == nil {
+		b.errs = append(b.errs, errors.New("clock is nil"))
+	}
+
+	b.clock = clock
+	return b
+}
+
+// WithMapOptions sets the options of the underlying [maps.Bucketted] map, such as [maps.WithPointerFree]
+func (b *Builder[K, V]) WithMapOptions(opts ...options.Option[maps.Options]) *Builder[K, V] {
+	b.map_options = append(b.map_options, opts...)
+	return b
+}
+
+// Build creates the cache from the settings, returning the errors of all invalid settings
+func (b *Builder[K, V]) Build() (Cache[K, V], error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+
+	hasher := b.hasher
+	if hasher == nil {
+		hasher = hash.NewComparableHasher[K]()
+	}
+
+	if b.loader != nil {
+		return b.buildLoading(hasher)
+	}
+
+	return b.buildMemory(hasher)
+}
+
+func (b *Builder[K, V]) buildMemory(hasher hash.Hasher[K]) (Cache[K, V], error) {
+	opts := b.map_options
+	if b.weigher != nil {
+		weigher := b.weigher
+		opts = append(opts,
+			maps.WithWeigher(maps.WeigherFunc[K, entry[V]](func(key K, value entry[V]) uint64 { return weigher.Weigh(key, value.value) })),
+			maps.WithMaxWeight(b.max_weight),
+		)
+	}
+
+	data, err := maps.NewBuckettedMap[K, entry[V]](b.capacity, hasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memory[K, V]{
+		data:  data,
+		ttl:   b.ttl,
+		clock: b.clock,
+	}, nil
+}
+
+func (b *Builder[K, V]) buildLoading(hasher hash.Hasher[K]) (Cache[K, V], error) {
+	ttl := b.ttl
+	if ttl == 0 {
+		ttl = never
+	}
+
+	opts := []options.Option[maps.LoadingOptions]{
+		maps.WithTTL(ttl),
+		maps.WithClock(b.clock),
+		maps.WithMapOptions(b.map_options...),
+	}
+	if b.weigher != nil {
+		opts = append(opts, maps.WithLoadedWeigher(b.weigher, b.max_weight))
+	}
+	opts = append(opts, b.loading_options...)
+
+	data, err := maps.NewLoadingMap(b.capacity, hasher, b.loader, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loading[K, V]{data}, nil
+}
+
+func (b *Builder[K, V]) String() string {
+	return fmt.Sprintf("cache.Builder[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (b *Builder[K, V]) GoString() string {
+	return b.String()
+}
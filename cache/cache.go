@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"iter"
+
+	"github.com/daanv2/go-cache/maps"
+)
+
+// Cache is a concurrent key value cache, created through a [Builder].
+type Cache[K, V comparable] interface {
+	// Get returns the value for the key and true if it was found or loaded, expired values are not returned
+	Get(ctx context.Context, key K) (V, bool, error)
+	// Set stores the value for the key, replacing the current one
+	Set(ctx context.Context, key K, value V) error
+	// Delete removes the value for the key, returning true if it was present
+	Delete(ctx context.Context, key K) (bool, error)
+	// Read returns a sequence of the items in the cache. Items changed while reading may or may not be seen
+	Read() iter.Seq[maps.KeyValue[K, V]]
+	// Close releases the resources of the cache, it should not be used afterwards
+	Close() error
+}
@@ -0,0 +1,174 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/cache"
+	"github.com/daanv2/go-cache/maps"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func Test_Cache_Memory(t *testing.T) {
+	ctx := context.Background()
+	col, err := cache.New[string, int]().WithCapacity(100).Build()
+	require.NoError(t, err)
+	defer col.Close()
+
+	for i := range 100 {
+		require.NoError(t, col.Set(ctx, fmt.Sprint(i), i))
+	}
+
+	v, ok, err := col.Get(ctx, "10")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 10, v)
+
+	ok, err = col.Delete(ctx, "10")
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = col.Get(ctx, "10")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	count := 0
+	for item := range col.Read() {
+		require.Equal(t, fmt.Sprint(item.Value), item.Key)
+		count++
+	}
+	require.Equal(t, 99, count)
+}
+
+func Test_Cache_TTL(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	col, err := cache.New[int, string]().WithTTL(time.Minute).WithClock(clock.Now).Build()
+	require.NoError(t, err)
+
+	require.NoError(t, col.Set(ctx, 1, "a"))
+	clock.Advance(30 * time.Second)
+	require.NoError(t, col.Set(ctx, 2, "b"))
+
+	v, ok, err := col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "a", v)
+
+	clock.Advance(30 * time.Second)
+	_, ok, _ = col.Get(ctx, 1)
+	require.False(t, ok)
+	_, ok, _ = col.Get(ctx, 2)
+	require.True(t, ok)
+
+	ok, _ = col.Delete(ctx, 1)
+	require.False(t, ok)
+}
+
+func Test_Cache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	col, err := cache.New[int, string]().
+		WithEviction(maps.WeigherFunc[int, string](func(key int, value string) uint64 { return uint64(len(value)) }), 100).
+		WithMapOptions(maps.WithBucketAmount(1)).
+		Build()
+	require.NoError(t, err)
+
+	for i := range 100 {
+		require.NoError(t, col.Set(ctx, i, "0123456789"))
+	}
+
+	count := 0
+	for range col.Read() {
+		count++
+	}
+	require.Equal(t, 10, count)
+
+	// The item that was just added is kept, others are evicted in slot order
+	_, ok, _ := col.Get(ctx, 99)
+	require.True(t, ok)
+}
+
+func Test_Cache_Loader(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	loads := 0
+	col, err := cache.New[int, string]().
+		WithTTL(time.Minute).
+		WithClock(clock.Now).
+		WithLoader(func(ctx context.Context, key int) (string, error) {
+			if key < 0 {
+				return "", errors.New("negative key")
+			}
+
+			loads++
+			return fmt.Sprintf("%d-%d", key, loads), nil
+		}).
+		Build()
+	require.NoError(t, err)
+	defer col.Close()
+
+	v, ok, err := col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "1-1", v)
+	v, _, _ = col.Get(ctx, 1)
+	require.Equal(t, "1-1", v)
+
+	clock.Advance(time.Minute)
+	v, _, _ = col.Get(ctx, 1)
+	require.Equal(t, "1-2", v)
+
+	_, ok, err = col.Get(ctx, -1)
+	require.Error(t, err)
+	require.False(t, ok)
+}
+
+func Test_Cache_LoaderNotFound(t *testing.T) {
+	ctx := context.Background()
+	loads := 0
+	col, err := cache.New[int, string]().
+		WithLoader(func(ctx context.Context, key int) (string, error) {
+			loads++
+			return "", maps.ErrNotFound
+		}, maps.WithNegativeTTL(time.Minute)).
+		Build()
+	require.NoError(t, err)
+	defer col.Close()
+
+	for range 3 {
+		_, ok, err := col.Get(ctx, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+	require.Equal(t, 1, loads)
+}
+
+func Test_Cache_Invalid(t *testing.T) {
+	_, err := cache.New[int, string]().WithCapacity(0).WithTTL(-time.Second).WithLoader(nil).Build()
+	require.Error(t, err)
+	require.ErrorContains(t, err, "capacity")
+	require.ErrorContains(t, err, "ttl")
+	require.ErrorContains(t, err, "loader")
+}
@@ -0,0 +1,3 @@
+// cache is the package that puts the collections of this module behind a single [Cache] interface.
+// A [Builder], see [New], composes a map with expiry, eviction and loading from the chosen settings.
+package cache
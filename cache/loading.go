@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-kit/generics"
+)
+
+var _ Cache[string, string] = &loading[string, string]{}
+
+// never is the ttl of loaded values when the cache has none, as [maps.Loading] always expires its values
+const never = 100 * 365 * 24 * time.Hour
+
+// loading is a [Cache] that loads missing values through a [maps.Loading]
+type loading[K, V comparable] struct {
+	data *maps.Loading[K, V]
+}
+
+// Get implements Cache, keys the loader reports as [maps.ErrNotFound] are not found without an error.
+func (l *loading[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	v, err := l.data.Get(ctx, key)
+	if errors.Is(err, maps.ErrNotFound) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+
+	return v, true, nil
+}
+
+// Set implements Cache.
+func (l *loading[K, V]) Set(ctx context.Context, key K, value V) error {
+	l.data.Set(key, value)
+	return nil
+}
+
+// Delete implements Cache.
+func (l *loading[K, V]) Delete(ctx context.Context, key K) (bool, error) {
+	_, ok := l.data.Delete(key)
+	return ok, nil
+}
+
+// Read implements Cache, including expired values that have not been reloaded yet.
+func (l *loading[K, V]) Read() iter.Seq[maps.KeyValue[K, V]] {
+	return l.data.Read()
+}
+
+// Close implements Cache, waiting for the background refreshes to finish.
+func (l *loading[K, V]) Close() error {
+	l.data.Wait()
+	return nil
+}
+
+func (l *loading[K, V]) String() string {
+	return fmt.Sprintf("cache.Cache[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (l *loading[K, V]) GoString() string {
+	return l.String()
+}
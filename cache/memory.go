@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-kit/generics"
+)
+
+var _ Cache[string, string] = &memory[string, string]{}
+
+// entry is a value with the moment it expires, the zero time never expires
+type entry[V comparable] struct {
+	value   V
+	expires time.Time
+}
+
+// memory is a [Cache] kept in a [maps.Bucketted], expired values are removed when they are found
+type memory[K, V comparable] struct {
+	data  *maps.Bucketted[K, entry[V]]
+	ttl   time.Duration
+	clock func() time.Time
+}
+
+func (m *memory[K, V]) expired(e entry[V], now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// Get implements Cache.
+func (m *memory[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	item, ok := m.data.Get(key)
+	if !ok {
+		return item.Value.value, false, nil
+	}
+	if m.expired(item.Value, m.clock()) {
+		// Only remove it if it was not replaced in the mean time
+		m.data.CompareAndDelete(key, item.Value)
+
+		var empty V
+		return empty, false, nil
+	}
+
+	return item.Value.value, true, nil
+}
+
+// Set implements Cache.
+func (m *memory[K, V]) Set(ctx context.Context, key K, value V) error {
+	e := entry[V]{value: value}
+	if m.ttl > 0 {
+		e.expires = m.clock().Add(m.ttl)
+	}
+
+	m.data.Set(key, e)
+	return nil
+}
+
+// Delete implements Cache.
+func (m *memory[K, V]) Delete(ctx context.Context, key K) (bool, error) {
+	item, ok := m.data.Delete(key)
+	return ok && !m.expired(item.Value, m.clock()), nil
+}
+
+// Read implements Cache.
+func (m *memory[K, V]) Read() iter.Seq[maps.KeyValue[K, V]] {
+	return func(yield func(maps.KeyValue[K, V]) bool) {
+		now := m.clock()
+		for item := range m.data.Read() {
+			if m.expired(item.Value, now) {
+				continue
+			}
+			if !yield(maps.NewKeyValue(item.Hash, item.Key, item.Value.value)) {
+				return
+			}
+		}
+	}
+}
+
+// Close implements Cache.
+func (m *memory[K, V]) Close() error {
+	return nil
+}
+
+func (m *memory[K, V]) String() string {
+	return fmt.Sprintf("cache.Cache[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *memory[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,108 @@
+package large
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// Codec converts items from and to the bytes that are stored in the arenas.
+// Encodings of keys are compared byte wise, so they have to be deterministic.
+type Codec[T any] interface {
+	// Encode appends the encoded value to dst and returns the extended slice
+	Encode(dst []byte, value T) ([]byte, error)
+	// Decode returns the value encoded in data, data is not valid after the call returns
+	Decode(data []byte) (T, error)
+}
+
+// DefaultCodec returns the [StringCodec] for strings, the [FixedCodec] for fixed size types, ints and uints, and the [JSONCodec] for anything else
+func DefaultCodec[T any]() Codec[T] {
+	var empty T
+	switch any(empty).(type) {
+	case string:
+		return any(StringCodec()).(Codec[T])
+	case int:
+		return any(intCodec[int]{}).(Codec[T])
+	case uint:
+		return any(intCodec[uint]{}).(Codec[T])
+	}
+
+	if binary.Size(empty) > 0 {
+		return FixedCodec[T]()
+	}
+
+	return JSONCodec[T]()
+}
+
+type stringCodec struct{}
+
+// StringCodec stores strings as their raw bytes
+func StringCodec() Codec[string] {
+	return stringCodec{}
+}
+
+func (stringCodec) Encode(dst []byte, value string) ([]byte, error) {
+	return append(dst, value...), nil
+}
+
+func (stringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+type fixedCodec[T any] struct{}
+
+// FixedCodec stores fixed size values, such as sized integers, floats, and structs of them, in little endian order, see [binary.Size]
+func FixedCodec[T any]() Codec[T] {
+	return fixedCodec[T]{}
+}
+
+func (fixedCodec[T]) Encode(dst []byte, value T) ([]byte, error) {
+	return binary.Append(dst, binary.LittleEndian, value)
+}
+
+func (fixedCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	n, err := binary.Decode(data, binary.LittleEndian, &value)
+	if err == nil && n != len(data) {
+		err = errors.New("trailing data after fixed size value")
+	}
+
+	return value, err
+}
+
+// intCodec stores platform sized integers as 8 bytes
+type intCodec[I int | uint] struct{}
+
+func (intCodec[I]) Encode(dst []byte, value I) ([]byte, error) {
+	return binary.LittleEndian.AppendUint64(dst, uint64(value)), nil
+}
+
+func (intCodec[I]) Decode(data []byte) (I, error) {
+	if len(data) != 8 {
+		return 0, errors.New("integer has to be 8 bytes")
+	}
+
+	return I(binary.LittleEndian.Uint64(data)), nil
+}
+
+type jsonCodec[T any] struct{}
+
+// JSONCodec stores values as json
+func JSONCodec[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+func (jsonCodec[T]) Encode(dst []byte, value T) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return dst, err
+	}
+
+	return append(dst, data...), nil
+}
+
+func (jsonCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
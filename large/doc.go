@@ -1,2 +1,3 @@
-// large is a package for caches of very large amount of items, thinks millions
+// large is a package for caches of very large amount of items, thinks millions.
+// [Map] keeps its entries in byte arenas in memory, [Mapped] keeps them in memory mapped files.
 package large
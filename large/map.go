@@ -0,0 +1,268 @@
+package large
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"sync"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/iterators"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// ErrEntryTooLarge is returned when an encoded entry does not fit in 4GiB
+var ErrEntryTooLarge = errors.New("entry is too large to be stored")
+
+// Map is a concurrent map for tens of millions of entries. Keys and values are encoded with a [Codec] into large
+// pre-allocated byte arenas, with an index of offsets, so entries do not create pointers for the garbage collector to scan.
+// Each key hashes to one of the shards, each shard has its own lock and arenas, and compacts the space of
+// overwritten and deleted entries once it exceeds the compact ratio, see [WithCompactRatio].
+type Map[K comparable, V any] struct {
+	hasher  hash.Hasher[K]
+	keys    Codec[K]
+	values  Codec[V]
+	shards  []*shard
+	buffers sync.Pool
+	base    Options
+}
+
+// NewMap creates a new Map with the specified capacity, hasher, and options.
+func NewMap[K comparable, V any](capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*Map[K, V], error) {
+	base, err := CreateOptions[maps.KeyValue[K, V]](opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := codecFor[K](base.key_codec)
+	if err != nil {
+		return nil, err
+	}
+	values, err := codecFor[V](base.value_codec)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := base.bucket_amount
+	if amount == 0 {
+		amount = base.BucketAmount(capacity)
+	}
+	amount = max(amount, 1)
+
+	m := &Map[K, V]{
+		hasher:  keyhasher,
+		keys:    keys,
+		values:  values,
+		shards:  make([]*shard, 0, amount),
+		buffers: sync.Pool{New: func() any { return new([]byte) }},
+		base:    base,
+	}
+
+	for range amount {
+		m.shards = append(m.shards, newShard(capacity/amount, base))
+	}
+
+	return m, nil
+}
+
+func codecFor[T any](codec any) (Codec[T], error) {
+	if codec == nil {
+		return DefaultCodec[T](), nil
+	}
+
+	c, ok := codec.(Codec[T])
+	if !ok {
+		return nil, fmt.Errorf("codec %T does not encode %s", codec, generics.NameOf[T]())
+	}
+
+	return c, nil
+}
+
+// Get retrieves the value for the specified key from the Map. Entries that can not be decoded are reported as missing.
+func (m *Map[K, V]) Get(key K) (maps.KeyValue[K, V], bool) {
+	buf := m.buffers.Get().(*[]byte)
+	defer m.buffers.Put(buf)
+
+	h := m.hasher.Hash(key)
+	k, err := m.keys.Encode((*buf)[:0], key)
+	*buf = k
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	entry, ok := m.shard(h).get(h, k)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	v, err := m.values.Decode(entryValue(entry))
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	return maps.NewKeyValue(h, key, v), true
+}
+
+// Set will add or update the value for the specified key in the Map. It returns true if the value was added, false if it was updated.
+// An error is returned if the key or value could not be encoded.
+func (m *Map[K, V]) Set(key K, value V) (bool, error) {
+	buf := m.buffers.Get().(*[]byte)
+	defer m.buffers.Put(buf)
+
+	h := m.hasher.Hash(key)
+	entry, err := m.encode((*buf)[:0], h, key, value)
+	*buf = entry
+	if err != nil {
+		return false, err
+	}
+
+	return m.shard(h).put(entry), nil
+}
+
+// encode appends the entry for the key and value to dst
+func (m *Map[K, V]) encode(dst []byte, h uint64, key K, value V) ([]byte, error) {
+	entry := appendHeader(dst)
+	entry, err := m.keys.Encode(entry, key)
+	if err != nil {
+		return entry, err
+	}
+
+	klen := len(entry) - header_size
+	entry, err = m.values.Encode(entry, value)
+	if err != nil {
+		return entry, err
+	}
+	if len(entry) > math.MaxUint32 {
+		return entry, ErrEntryTooLarge
+	}
+
+	putHeader(entry, h, klen)
+	return entry, nil
+}
+
+// Delete removes the value for the specified key from the Map. It returns the removed item and true if it was found.
+func (m *Map[K, V]) Delete(key K) (maps.KeyValue[K, V], bool) {
+	buf := m.buffers.Get().(*[]byte)
+	defer m.buffers.Put(buf)
+
+	h := m.hasher.Hash(key)
+	k, err := m.keys.Encode((*buf)[:0], key)
+	*buf = k
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	entry, ok := m.shard(h).remove(h, k)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	// The entry is removed either way, a value that can not be decoded is returned empty
+	v, _ := m.values.Decode(entryValue(entry))
+	return maps.NewKeyValue(h, key, v), true
+}
+
+// Len returns the amount of entries in the Map
+func (m *Map[K, V]) Len() int {
+	total := 0
+	for _, s := range m.shards {
+		total += s.len()
+	}
+
+	return total
+}
+
+// Size returns the amount of bytes written to the arenas, including the entries that are not yet compacted
+func (m *Map[K, V]) Size() uint64 {
+	total := uint64(0)
+	for _, s := range m.shards {
+		total += s.size()
+	}
+
+	return total
+}
+
+// Compact compacts all shards, releasing the space of overwritten and deleted entries
+func (m *Map[K, V]) Compact() {
+	for _, s := range m.shards {
+		s.lock.Lock()
+		s.compact()
+		s.lock.Unlock()
+	}
+}
+
+func (m *Map[K, V]) shard(h uint64) *shard {
+	return m.shards[h%uint64(len(m.shards))]
+}
+
+// Read will return a sequence of all items in the map, without holding any locks while yielding.
+// Items changed while reading may or may not be seen, items that can not be decoded are skipped.
+func (m *Map[K, V]) Read() iter.Seq[maps.KeyValue[K, V]] {
+	return func(yield func(maps.KeyValue[K, V]) bool) {
+		for _, s := range m.shards {
+			for entry := range s.snapshot().entries() {
+				k, err := m.keys.Decode(entryKey(entry))
+				if err != nil {
+					continue
+				}
+				v, err := m.values.Decode(entryValue(entry))
+				if err != nil {
+					continue
+				}
+
+				if !yield(maps.NewKeyValue(entryHash(entry), k, v)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Keys will return a sequence of all keys in the map
+func (m *Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for item := range m.Read() {
+			if !yield(item.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values will return a sequence of all values in the map
+func (m *Map[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for item := range m.Read() {
+			if !yield(item.Value) {
+				return
+			}
+		}
+	}
+}
+
+// KeyValues will return a sequence of all items in the map
+func (m *Map[K, V]) KeyValues() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for item := range m.Read() {
+			if !yield(item.Key, item.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Range will iterate over all items in the map
+func (m *Map[K, V]) Range(yield func(item maps.KeyValue[K, V]) bool) {
+	iterators.RangeCol(m, yield)
+}
+
+func (m *Map[K, V]) String() string {
+	return fmt.Sprintf("large.Map[%s,%s,%v]", generics.NameOf[K](), generics.NameOf[V](), len(m.shards))
+}
+
+func (m *Map[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,176 @@
+package large_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/large"
+	"github.com/daanv2/go-cache/pkg/hash"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+type constantHasher struct{}
+
+func (constantHasher) Hash(item int) uint64 { return 42 }
+
+var _ hash.Hasher[int] = constantHasher{}
+
+type lengthHasher struct{}
+
+func (lengthHasher) Hash(item string) uint64 { return uint64(len(item)) }
+
+func Test_Map_GetSetDelete(t *testing.T) {
+	col, err := large.NewMap[int, string](1000, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		added, err := col.Set(i, fmt.Sprintf("value-%d", i))
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	require.Equal(t, 1000, col.Len())
+
+	for i := range 1000 {
+		v, ok := col.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v.Key)
+		require.Equal(t, fmt.Sprintf("value-%d", i), v.Value)
+	}
+
+	added, err := col.Set(5, "other")
+	require.NoError(t, err)
+	require.False(t, added)
+	v, ok := col.Get(5)
+	require.True(t, ok)
+	require.Equal(t, "other", v.Value)
+
+	v, ok = col.Delete(5)
+	require.True(t, ok)
+	require.Equal(t, "other", v.Value)
+	_, ok = col.Get(5)
+	require.False(t, ok)
+	_, ok = col.Delete(5)
+	require.False(t, ok)
+	require.Equal(t, 999, col.Len())
+
+	count := 0
+	for item := range col.Read() {
+		require.Equal(t, fmt.Sprintf("value-%d", item.Key), item.Value)
+		count++
+	}
+	require.Equal(t, 999, count)
+}
+
+func Test_Map_Collisions(t *testing.T) {
+	col, err := large.NewMap[int, int](100, constantHasher{}, large.WithBucketAmount(1))
+	require.NoError(t, err)
+
+	for i := range 100 {
+		_, err := col.Set(i, i*2)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 100; i += 3 {
+		_, ok := col.Delete(i)
+		require.True(t, ok)
+	}
+
+	for i := range 100 {
+		v, ok := col.Get(i)
+		require.Equal(t, i%3 != 0, ok, i)
+		if ok {
+			require.Equal(t, i*2, v.Value)
+		}
+	}
+}
+
+func Test_Map_Compaction(t *testing.T) {
+	col, err := large.NewMap[int, string](100, test_util.CheapIntHasher[int](),
+		large.WithBucketAmount(1),
+		large.WithArenaSize(4096),
+	)
+	require.NoError(t, err)
+
+	value := strings.Repeat("x", 100)
+	for range 100 {
+		for i := range 10 {
+			_, err := col.Set(i, value)
+			require.NoError(t, err)
+		}
+	}
+
+	// 1000 writes of 10 live entries, overwritten entries are reclaimed
+	require.Less(t, col.Size(), uint64(3*4096))
+	for i := range 10 {
+		v, ok := col.Get(i)
+		require.True(t, ok)
+		require.Equal(t, value, v.Value)
+	}
+
+	col.Compact()
+	require.Equal(t, 10, col.Len())
+	require.EqualValues(t, 10*(16+8+len(value)), col.Size())
+}
+
+func Test_Map_Codecs(t *testing.T) {
+	type point struct{ X, Y int32 }
+
+	col, err := large.NewMap[string, point](100, lengthHasher{})
+	require.NoError(t, err)
+
+	_, err = col.Set("a", point{1, 2})
+	require.NoError(t, err)
+	v, ok := col.Get("a")
+	require.True(t, ok)
+	require.Equal(t, point{1, 2}, v.Value)
+
+	_, err = large.NewMap[int, string](100, test_util.CheapIntHasher[int](), large.WithKeyCodec(large.StringCodec()))
+	require.Error(t, err)
+}
+
+func Test_Map_Concurrent(t *testing.T) {
+	col, err := large.NewMap[int, string](10_000, test_util.CheapIntHasher[int](), large.WithArenaSize(4096))
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 1000 {
+				key := w*1000 + i
+				_, err := col.Set(key, fmt.Sprint(key))
+				require.NoError(t, err)
+				_, err = col.Set(key, fmt.Sprint(key))
+				require.NoError(t, err)
+
+				v, ok := col.Get(key)
+				require.True(t, ok)
+				require.Equal(t, fmt.Sprint(key), v.Value)
+
+				if i%2 == 0 {
+					_, ok = col.Delete(key)
+					require.True(t, ok)
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for range 10 {
+			for item := range col.Read() {
+				require.Equal(t, fmt.Sprint(item.Key), item.Value)
+			}
+		}
+	}()
+	wg.Wait()
+
+	require.Equal(t, 4000, col.Len())
+}
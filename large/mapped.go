@@ -0,0 +1,1010 @@
+package large
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/iterators"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+var (
+	// ErrReadOnly is returned when changing a [Mapped] map that was opened with [WithReadOnly]
+	ErrReadOnly = errors.New("map is opened read only")
+	// ErrNotClean is returned when opening a [Mapped] map read only, that was not closed cleanly
+	ErrNotClean = errors.New("map was not closed cleanly, open it writable once to recover it")
+	// ErrCorrupted is returned when the files of a [Mapped] map are not recognized
+	ErrCorrupted = errors.New("map files are corrupted")
+	// ErrClosed is returned when using a [Mapped] map after it was closed
+	ErrClosed = errors.New("map is closed")
+)
+
+const (
+	index_magic       = "GOCACHEI"
+	index_header_size = 64
+	slot_size         = 16
+	slot_empty        = 0 // The offset of a slot that was never used
+	slot_deleted      = 1 // The offset of a slot whose entry was deleted, probing continues past it
+
+	log_magic       = "GOCACHEL"
+	log_header_size = 16
+	// record_header_size is the size of the header of a record: the checksum, key length, value length, flags, and hash
+	record_header_size = 24
+	record_tombstone   = 1
+
+	state_clean = 0
+	state_dirty = 1
+
+	min_log_size = 1024 * 1024
+)
+
+var crc_table = crc32.MakeTable(crc32.Castagnoli)
+
+// Mapped is a map backed by two memory mapped files in a directory, so it can hold more than fits in memory,
+// and be reopened instantly by other processes.
+//
+// The index file holds fixed width slots of the hash of the key and the offset of its record, probed linearly.
+// The log file holds the checksummed records of the keys and values, encoded with a [Codec], every change is appended.
+//
+// [Mapped.Sync] and [Mapped.Close] mark the files as clean once everything is flushed. The first change afterwards marks
+// them dirty again, opening dirty files rebuilds the index from the log, discarding a partially written record at its end.
+//
+// Overwritten and deleted records stay in the log until it is compacted, which happens once they exceed the compact ratio
+// of the log, see [WithCompactRatio], or through [Mapped.Compact].
+type Mapped[K comparable, V any] struct {
+	hasher     hash.Hasher[K]
+	keys       Codec[K]
+	values     Codec[V]
+	dir        string
+	read_only  bool
+	index_file *os.File
+	index      []byte // The mapped index file
+	log_file   *os.File
+	log        []byte // The mapped log file, records end at log_size, the rest is zeroed
+	log_size   uint64
+	slots      uint64
+	amount     uint64 // The amount of entries
+	used       uint64 // The amount of slots that are not empty, including deleted ones
+	dirty      bool   // If the header has been marked dirty since the last sync
+	garbage    uint64 // The amount of bytes of records in the log that are overwritten, deleted or tombstones
+	ratio      float64
+	readers    atomic.Int64 // The amount of reads in progress, compaction waits for them as it moves the records
+	buffer     []byte
+	lock       sync.RWMutex
+}
+
+// OpenMapped opens or creates the map in the directory, sized for capacity entries, the index grows if it is exceeded.
+func OpenMapped[K comparable, V any](dir string, capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*Mapped[K, V], error) {
+	base, err := CreateOptions[maps.KeyValue[K, V]](opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := codecFor[K](base.key_codec)
+	if err != nil {
+		return nil, err
+	}
+	values, err := codecFor[V](base.value_codec)
+	if err != nil {
+		return nil, err
+	}
+
+	if !base.read_only {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Mapped[K, V]{
+		hasher:    keyhasher,
+		keys:      keys,
+		values:    values,
+		dir:       dir,
+		read_only: base.read_only,
+		ratio:     base.compact_ratio,
+		lock:      sync.RWMutex{},
+	}
+
+	if err := m.open(slotsFor(capacity)); err != nil {
+		_ = m.release()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// slotsFor returns the amount of slots for the capacity, at a load of 75%
+func slotsFor(capacity uint64) uint64 {
+	return max(capacity+capacity/3, 16)
+}
+
+func (m *Mapped[K, V]) open(slots uint64) error {
+	flag := os.O_RDWR | os.O_CREATE
+	if m.read_only {
+		flag = os.O_RDONLY
+	}
+
+	var err error
+	m.log_file, err = os.OpenFile(filepath.Join(m.dir, "log"), flag, 0o644)
+	if err != nil {
+		return err
+	}
+	// The log is locked, as the index file is replaced when growing
+	if err := lockFile(m.log_file, !m.read_only); err != nil {
+		return fmt.Errorf("locking %s: %w", m.dir, err)
+	}
+	m.index_file, err = os.OpenFile(filepath.Join(m.dir, "index"), flag, 0o644)
+	if err != nil {
+		return err
+	}
+
+	stat, err := m.index_file.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.Size() == 0 {
+		return m.create(slots)
+	}
+
+	m.index, err = mmap(m.index_file, int(stat.Size()), !m.read_only)
+	if err != nil {
+		return err
+	}
+	if string(m.index[:8]) != index_magic {
+		return ErrCorrupted
+	}
+
+	m.slots = m.header(24)
+	m.amount = m.header(32)
+	m.used = m.header(40)
+	if uint64(stat.Size()) != index_header_size+m.slots*slot_size {
+		return ErrCorrupted
+	}
+
+	if m.header(8) == state_clean {
+		if err := m.mapLog(m.header(16)); err != nil {
+			return err
+		}
+
+		m.countGarbage()
+		return nil
+	}
+	if m.read_only {
+		return ErrNotClean
+	}
+
+	return m.recover(max(slots, m.slots))
+}
+
+// create initializes new files
+func (m *Mapped[K, V]) create(slots uint64) error {
+	if m.read_only {
+		return os.ErrNotExist
+	}
+
+	if err := m.log_file.Truncate(0); err != nil {
+		return err
+	}
+	header := make([]byte, log_header_size)
+	copy(header, log_magic)
+	if _, err := m.log_file.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err := m.mapLog(log_header_size); err != nil {
+		return err
+	}
+
+	index, err := m.createIndex(m.index_file, slots)
+	if err != nil {
+		return err
+	}
+	m.index = index
+
+	return m.sync()
+}
+
+// recover rebuilds the index from the log, after the map was not closed cleanly
+func (m *Mapped[K, V]) recover(slots uint64) error {
+	stat, err := m.log_file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := m.mapLog(uint64(stat.Size())); err != nil {
+		return err
+	}
+	if string(m.log[:8]) != log_magic {
+		return ErrCorrupted
+	}
+
+	if err := munmap(m.index); err != nil {
+		return err
+	}
+	m.index, err = m.createIndex(m.index_file, slots)
+	if err != nil {
+		return err
+	}
+	m.setHeader(8, state_dirty)
+	m.dirty = true
+	m.garbage = 0
+
+	offset := uint64(log_header_size)
+	for {
+		record, ok := m.validRecord(offset)
+		if !ok {
+			break
+		}
+
+		h := binary.LittleEndian.Uint64(record[16:])
+		if err := m.apply(h, recordKey(record), offset, recordFlags(record)&record_tombstone != 0); err != nil {
+			return err
+		}
+		offset += uint64(len(record))
+	}
+
+	// Drop whatever came after the last complete record, so it can not be mistaken for a record later on
+	if err := munmap(m.log); err != nil {
+		return err
+	}
+	m.log = nil
+	if err := m.log_file.Truncate(int64(offset)); err != nil {
+		return err
+	}
+	if err := m.mapLog(offset); err != nil {
+		return err
+	}
+
+	return m.sync()
+}
+
+// record returns the record at the offset, without verifying its checksum
+func (m *Mapped[K, V]) record(offset uint64) ([]byte, bool) {
+	if offset+record_header_size > uint64(len(m.log)) {
+		return nil, false
+	}
+
+	header := m.log[offset : offset+record_header_size]
+	size := record_header_size + uint64(binary.LittleEndian.Uint32(header[4:])) + uint64(binary.LittleEndian.Uint32(header[8:]))
+	if offset+size > uint64(len(m.log)) {
+		return nil, false
+	}
+
+	return m.log[offset : offset+size], true
+}
+
+// validRecord returns the complete record at the offset, if its checksum matches
+func (m *Mapped[K, V]) validRecord(offset uint64) ([]byte, bool) {
+	record, ok := m.record(offset)
+	if !ok || binary.LittleEndian.Uint32(record) != crc32.Checksum(record[4:], crc_table) {
+		return nil, false
+	}
+
+	return record, true
+}
+
+// createIndex sizes the file for the amount of slots and maps it, with all slots empty
+func (m *Mapped[K, V]) createIndex(file *os.File, slots uint64) ([]byte, error) {
+	size := index_header_size + slots*slot_size
+	if err := file.Truncate(0); err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(int64(size)); err != nil {
+		return nil, err
+	}
+
+	index, err := mmap(file, int(size), true)
+	if err != nil {
+		return nil, err
+	}
+
+	copy(index, index_magic)
+	binary.LittleEndian.PutUint64(index[8:], state_dirty)
+	binary.LittleEndian.PutUint64(index[24:], slots)
+	m.slots = slots
+	m.amount = 0
+	m.used = 0
+
+	return index, nil
+}
+
+// mapLog maps the log file, with room for at least size bytes, records end at size
+func (m *Mapped[K, V]) mapLog(size uint64) error {
+	stat, err := m.log_file.Stat()
+	if err != nil {
+		return err
+	}
+	if uint64(stat.Size()) < size || size < log_header_size {
+		return ErrCorrupted
+	}
+
+	mapped := uint64(stat.Size())
+	if !m.read_only && mapped < min_log_size {
+		mapped = min_log_size
+		if err := m.log_file.Truncate(int64(mapped)); err != nil {
+			return err
+		}
+	}
+
+	m.log, err = mmap(m.log_file, int(mapped), !m.read_only)
+	if err != nil {
+		return err
+	}
+	m.log_size = size
+
+	return nil
+}
+
+// reserve grows the log until it has room for n more bytes
+func (m *Mapped[K, V]) reserve(n uint64) error {
+	if m.log_size+n <= uint64(len(m.log)) {
+		return nil
+	}
+
+	page := uint64(os.Getpagesize())
+	size := max(uint64(len(m.log))*2, m.log_size+n)
+	size = (size + page - 1) / page * page
+
+	if err := munmap(m.log); err != nil {
+		return err
+	}
+	m.log = nil
+	if err := m.log_file.Truncate(int64(size)); err != nil {
+		return err
+	}
+
+	var err error
+	m.log, err = mmap(m.log_file, int(size), true)
+	return err
+}
+
+// grow moves the index to a file with twice the slots
+func (m *Mapped[K, V]) grow() error {
+	path := filepath.Join(m.dir, "index")
+	file, err := os.OpenFile(path+".grow", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	old, slots, amount := m.index, m.slots, m.amount
+	index, err := m.createIndex(file, max(slots*2, 16))
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	m.index = index
+	for i := range slots {
+		s := old[index_header_size+i*slot_size:]
+		if offset := binary.LittleEndian.Uint64(s[8:]); offset > slot_deleted {
+			slot, _ := m.find(binary.LittleEndian.Uint64(s), nil)
+			m.setSlot(slot, binary.LittleEndian.Uint64(s), offset)
+			m.used++
+		}
+	}
+	m.amount = amount
+
+	if err := os.Rename(path+".grow", path); err != nil {
+		return err
+	}
+	_ = munmap(old)
+	_ = m.index_file.Close()
+	m.index_file = file
+
+	return nil
+}
+
+func (m *Mapped[K, V]) header(at int) uint64 {
+	return binary.LittleEndian.Uint64(m.index[at:])
+}
+
+func (m *Mapped[K, V]) setHeader(at int, value uint64) {
+	binary.LittleEndian.PutUint64(m.index[at:], value)
+}
+
+func (m *Mapped[K, V]) slot(i uint64) (uint64, uint64) {
+	s := m.index[index_header_size+i*slot_size:]
+	return binary.LittleEndian.Uint64(s), binary.LittleEndian.Uint64(s[8:])
+}
+
+func (m *Mapped[K, V]) setSlot(i uint64, h uint64, offset uint64) {
+	s := m.index[index_header_size+i*slot_size:]
+	binary.LittleEndian.PutUint64(s, h)
+	binary.LittleEndian.PutUint64(s[8:], offset)
+}
+
+// find returns the slot holding the key, or the slot it should be placed in and false.
+// A nil key matches nothing, and only looks for a free slot
+func (m *Mapped[K, V]) find(h uint64, key []byte) (uint64, bool) {
+	free := uint64(math.MaxUint64)
+	i := h % m.slots
+
+	for range m.slots {
+		sh, offset := m.slot(i)
+		switch {
+		case offset == slot_empty:
+			if free == math.MaxUint64 {
+				free = i
+			}
+			return free, false
+		case offset == slot_deleted:
+			if free == math.MaxUint64 {
+				free = i
+			}
+		case key != nil && sh == h:
+			if record, ok := m.record(offset); ok && bytes.Equal(recordKey(record), key) {
+				return i, true
+			}
+		}
+
+		i++
+		if i == m.slots {
+			i = 0
+		}
+	}
+
+	return free, false
+}
+
+// apply points the index at the record at the offset, or removes the key if it is a tombstone. Returns if the key was added
+func (m *Mapped[K, V]) apply(h uint64, key []byte, offset uint64, tombstone bool) error {
+	slot, found := m.find(h, key)
+	if found {
+		// The record it pointed at is no longer needed
+		_, old := m.slot(slot)
+		m.garbage += m.recordSize(old)
+	}
+	if tombstone {
+		m.garbage += m.recordSize(offset)
+		if found {
+			m.setSlot(slot, h, slot_deleted)
+			m.amount--
+		}
+		return nil
+	}
+
+	if found {
+		m.setSlot(slot, h, offset)
+		return nil
+	}
+
+	if (m.used+1)*4 > m.slots*3 {
+		if err := m.grow(); err != nil {
+			return err
+		}
+		slot, _ = m.find(h, key)
+	}
+
+	if _, old := m.slot(slot); old == slot_empty {
+		m.used++
+	}
+	m.setSlot(slot, h, offset)
+	m.amount++
+
+	return nil
+}
+
+// recordSize returns the size of the record at the offset, 0 if there is none
+func (m *Mapped[K, V]) recordSize(offset uint64) uint64 {
+	record, ok := m.record(offset)
+	if !ok {
+		return 0
+	}
+
+	return uint64(len(record))
+}
+
+// countGarbage sets the amount of garbage to the bytes of the log that no slot points at
+func (m *Mapped[K, V]) countGarbage() {
+	live := uint64(0)
+	for i := range m.slots {
+		if _, offset := m.slot(i); offset > slot_deleted {
+			live += m.recordSize(offset)
+		}
+	}
+
+	m.garbage = m.log_size - log_header_size - min(live, m.log_size-log_header_size)
+}
+
+// maybeCompact compacts the log once the garbage exceeds the compact ratio of it, and at least the minimum log size
+func (m *Mapped[K, V]) maybeCompact() error {
+	if m.garbage < min_log_size || float64(m.garbage) < float64(m.log_size-log_header_size)*m.ratio {
+		return nil
+	}
+
+	return m.compact()
+}
+
+// compact rewrites the log with only the records the slots point at, unless reads are in progress.
+// The new log replaces the old one while the index is marked dirty, so a crash in between rebuilds the index from whichever log is in place.
+func (m *Mapped[K, V]) compact() error {
+	if m.readers.Load() > 0 {
+		return nil
+	}
+
+	path := filepath.Join(m.dir, "log")
+	file, err := os.OpenFile(path+".compact", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	type moved struct{ slot, offset uint64 }
+	records, size, err := func() ([]moved, uint64, error) {
+		// Locked before it replaces the log, so other processes can not open it in between
+		if err := lockFile(file, true); err != nil {
+			return nil, 0, err
+		}
+
+		out := bufio.NewWriter(file)
+		header := make([]byte, log_header_size)
+		copy(header, log_magic)
+		if _, err := out.Write(header); err != nil {
+			return nil, 0, err
+		}
+
+		records := make([]moved, 0, m.amount)
+		size := uint64(log_header_size)
+		for i := range m.slots {
+			_, offset := m.slot(i)
+			if offset <= slot_deleted {
+				continue
+			}
+
+			record, ok := m.record(offset)
+			if !ok {
+				records = append(records, moved{i, slot_deleted})
+				continue
+			}
+			if _, err := out.Write(record); err != nil {
+				return nil, 0, err
+			}
+			records = append(records, moved{i, size})
+			size += uint64(len(record))
+		}
+
+		if err := out.Flush(); err != nil {
+			return nil, 0, err
+		}
+		return records, size, file.Sync()
+	}()
+	if err == nil {
+		err = m.markDirty()
+	}
+	if err == nil {
+		err = os.Rename(path+".compact", path)
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(path + ".compact")
+		return err
+	}
+
+	errs := []error{munmap(m.log), m.log_file.Close()}
+	m.log = nil
+	m.log_file = file
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if err := m.mapLog(size); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		h, _ := m.slot(r.slot)
+		m.setSlot(r.slot, h, r.offset)
+		if r.offset == slot_deleted {
+			m.amount--
+		}
+	}
+	m.garbage = 0
+
+	return nil
+}
+
+// append writes the record to the end of the log, returning its offset
+func (m *Mapped[K, V]) append(record []byte) (uint64, error) {
+	if err := m.markDirty(); err != nil {
+		return 0, err
+	}
+	if err := m.reserve(uint64(len(record))); err != nil {
+		return 0, err
+	}
+
+	offset := m.log_size
+	copy(m.log[offset:], record)
+	m.log_size += uint64(len(record))
+
+	return offset, nil
+}
+
+// markDirty durably marks the files as dirty before the first change after a sync
+func (m *Mapped[K, V]) markDirty() error {
+	if m.dirty {
+		return nil
+	}
+
+	m.setHeader(8, state_dirty)
+	if err := msync(m.index[:index_header_size]); err != nil {
+		return err
+	}
+
+	m.dirty = true
+	return nil
+}
+
+// encode encodes the record for the key, and value unless it is a tombstone, into the buffer
+func (m *Mapped[K, V]) encode(h uint64, key K, value V, tombstone bool) ([]byte, error) {
+	record := append(m.buffer[:0], make([]byte, record_header_size)...)
+	record, err := m.keys.Encode(record, key)
+	if err != nil {
+		return nil, err
+	}
+
+	klen := len(record) - record_header_size
+	flags := uint32(0)
+	if tombstone {
+		flags = record_tombstone
+	} else if record, err = m.values.Encode(record, value); err != nil {
+		return nil, err
+	}
+	if len(record) > math.MaxUint32 {
+		return nil, ErrEntryTooLarge
+	}
+	m.buffer = record
+
+	binary.LittleEndian.PutUint32(record[4:], uint32(klen))
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(record)-record_header_size-klen))
+	binary.LittleEndian.PutUint32(record[12:], flags)
+	binary.LittleEndian.PutUint64(record[16:], h)
+	binary.LittleEndian.PutUint32(record, crc32.Checksum(record[4:], crc_table))
+
+	return record, nil
+}
+
+func (m *Mapped[K, V]) writable() error {
+	if m.index == nil {
+		return ErrClosed
+	}
+	if m.read_only {
+		return ErrReadOnly
+	}
+
+	return nil
+}
+
+// Get retrieves the value for the specified key from the map. Entries that can not be decoded are reported as missing.
+func (m *Mapped[K, V]) Get(key K) (maps.KeyValue[K, V], bool) {
+	h := m.hasher.Hash(key)
+	k, err := m.keys.Encode(nil, key)
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.index == nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	slot, ok := m.find(h, k)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	_, offset := m.slot(slot)
+	record, ok := m.record(offset)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+	v, err := m.values.Decode(recordValue(record))
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	return maps.NewKeyValue(h, key, v), true
+}
+
+// Set will add or update the value for the specified key in the map. It returns true if the value was added, false if it was updated.
+func (m *Mapped[K, V]) Set(key K, value V) (bool, error) {
+	h := m.hasher.Hash(key)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if err := m.writable(); err != nil {
+		return false, err
+	}
+
+	record, err := m.encode(h, key, value, false)
+	if err != nil {
+		return false, err
+	}
+
+	offset, err := m.append(record)
+	if err != nil {
+		return false, err
+	}
+
+	amount := m.amount
+	if err := m.apply(h, recordKey(record), offset, false); err != nil {
+		return false, err
+	}
+
+	return m.amount > amount, m.maybeCompact()
+}
+
+// Delete removes the value for the specified key from the map. It returns the removed item and true if it was found.
+func (m *Mapped[K, V]) Delete(key K) (maps.KeyValue[K, V], bool, error) {
+	h := m.hasher.Hash(key)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if err := m.writable(); err != nil {
+		return maps.EmptyKeyValue[K, V](), false, err
+	}
+
+	var empty V
+	record, err := m.encode(h, key, empty, true)
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false, err
+	}
+
+	slot, ok := m.find(h, recordKey(record))
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false, nil
+	}
+
+	// Decode the value before appending, which can remap the log
+	_, offset := m.slot(slot)
+	old, ok := m.record(offset)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false, ErrCorrupted
+	}
+	v, _ := m.values.Decode(recordValue(old))
+
+	offset, err = m.append(record)
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false, err
+	}
+	if err := m.apply(h, recordKey(record), offset, true); err != nil {
+		return maps.EmptyKeyValue[K, V](), false, err
+	}
+
+	return maps.NewKeyValue(h, key, v), true, m.maybeCompact()
+}
+
+// Len returns the amount of entries in the map
+func (m *Mapped[K, V]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return int(m.amount)
+}
+
+// Compact rewrites the log with only the records of the current entries, releasing the space of overwritten and deleted ones.
+// While a [Mapped.Read] is in progress it is left to a later change, as it moves the records.
+func (m *Mapped[K, V]) Compact() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if err := m.writable(); err != nil {
+		return err
+	}
+
+	return m.compact()
+}
+
+// Size returns the amount of bytes of records in the log, including the ones that have been overwritten or deleted
+func (m *Mapped[K, V]) Size() uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.log_size
+}
+
+// Sync flushes all changes to disk and marks the files as clean
+func (m *Mapped[K, V]) Sync() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.sync()
+}
+
+func (m *Mapped[K, V]) sync() error {
+	if m.read_only {
+		return nil
+	}
+	if m.index == nil {
+		return ErrClosed
+	}
+
+	if err := msync(m.log); err != nil {
+		return err
+	}
+	if err := msync(m.index); err != nil {
+		return err
+	}
+
+	m.setHeader(16, m.log_size)
+	m.setHeader(24, m.slots)
+	m.setHeader(32, m.amount)
+	m.setHeader(40, m.used)
+	m.setHeader(8, state_clean)
+	if err := msync(m.index[:index_header_size]); err != nil {
+		return err
+	}
+
+	m.dirty = false
+	return nil
+}
+
+// Close syncs the map and releases its files, the map can not be used afterwards
+func (m *Mapped[K, V]) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.index == nil {
+		return ErrClosed
+	}
+
+	return errors.Join(m.sync(), m.release())
+}
+
+// release unmaps and closes the files
+func (m *Mapped[K, V]) release() error {
+	var errs []error
+	if m.index != nil {
+		errs = append(errs, munmap(m.index))
+		m.index = nil
+	}
+	if m.log != nil {
+		errs = append(errs, munmap(m.log))
+		m.log = nil
+	}
+	if m.index_file != nil {
+		errs = append(errs, m.index_file.Close())
+	}
+	if m.log_file != nil {
+		errs = append(errs, m.log_file.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Read will return a sequence of all items in the map, without holding any locks while yielding.
+// Items changed while reading may or may not be seen, items that can not be decoded are skipped.
+func (m *Mapped[K, V]) Read() iter.Seq[maps.KeyValue[K, V]] {
+	return func(yield func(maps.KeyValue[K, V]) bool) {
+		// Records are never changed once written and not moved while reading, so their offsets stay valid
+		m.readers.Add(1)
+		defer m.readers.Add(-1)
+		offsets := m.offsets()
+
+		for _, offset := range offsets {
+			item, ok := m.decode(offset)
+			if !ok {
+				continue
+			}
+
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Mapped[K, V]) offsets() []uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.index == nil {
+		return nil
+	}
+
+	offsets := make([]uint64, 0, m.amount)
+	for i := range m.slots {
+		if _, offset := m.slot(i); offset > slot_deleted {
+			offsets = append(offsets, offset)
+		}
+	}
+
+	return offsets
+}
+
+func (m *Mapped[K, V]) decode(offset uint64) (maps.KeyValue[K, V], bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.log == nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	record, ok := m.record(offset)
+	if !ok {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	k, err := m.keys.Decode(recordKey(record))
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+	v, err := m.values.Decode(recordValue(record))
+	if err != nil {
+		return maps.EmptyKeyValue[K, V](), false
+	}
+
+	return maps.NewKeyValue(binary.LittleEndian.Uint64(record[16:]), k, v), true
+}
+
+// Keys will return a sequence of all keys in the map
+func (m *Mapped[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for item := range m.Read() {
+			if !yield(item.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values will return a sequence of all values in the map
+func (m *Mapped[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for item := range m.Read() {
+			if !yield(item.Value) {
+				return
+			}
+		}
+	}
+}
+
+// KeyValues will return a sequence of all items in the map
+func (m *Mapped[K, V]) KeyValues() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for item := range m.Read() {
+			if !yield(item.Key, item.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Range will iterate over all items in the map
+func (m *Mapped[K, V]) Range(yield func(item maps.KeyValue[K, V]) bool) {
+	iterators.RangeCol(m, yield)
+}
+
+func (m *Mapped[K, V]) String() string {
+	return fmt.Sprintf("large.Mapped[%s,%s,%s]", generics.NameOf[K](), generics.NameOf[V](), m.dir)
+}
+
+func (m *Mapped[K, V]) GoString() string {
+	return m.String()
+}
+
+func recordFlags(record []byte) uint32 {
+	return binary.LittleEndian.Uint32(record[12:])
+}
+
+func recordKey(record []byte) []byte {
+	klen := binary.LittleEndian.Uint32(record[4:])
+	return record[record_header_size : record_header_size+klen]
+}
+
+func recordValue(record []byte) []byte {
+	klen := binary.LittleEndian.Uint32(record[4:])
+	return record[record_header_size+klen:]
+}
@@ -0,0 +1,235 @@
+//go:build linux
+
+package large_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/daanv2/go-cache/large"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mapped_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	// Grows past the initial capacity
+	for i := range 1000 {
+		added, err := col.Set(i, fmt.Sprintf("value-%d", i))
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	added, err := col.Set(1, "other")
+	require.NoError(t, err)
+	require.False(t, added)
+
+	v, ok, err := col.Delete(2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "value-2", v.Value)
+	require.NoError(t, col.Close())
+
+	col, err = large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int](), large.WithReadOnly())
+	require.NoError(t, err)
+	defer col.Close()
+
+	require.Equal(t, 999, col.Len())
+	v, ok = col.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "other", v.Value)
+	_, ok = col.Get(2)
+	require.False(t, ok)
+	v, ok = col.Get(999)
+	require.True(t, ok)
+	require.Equal(t, "value-999", v.Value)
+
+	_, err = col.Set(1, "value")
+	require.ErrorIs(t, err, large.ErrReadOnly)
+
+	count := 0
+	for item := range col.Read() {
+		if item.Key != 1 {
+			require.Equal(t, fmt.Sprintf("value-%d", item.Key), item.Value)
+		}
+		count++
+	}
+	require.Equal(t, 999, count)
+}
+
+func Test_Mapped_Exclusive(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	defer col.Close()
+
+	_, err = large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.Error(t, err)
+	_, err = large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int](), large.WithReadOnly())
+	require.Error(t, err)
+}
+
+// Test_Mapped_Crash writes to the map in a child process that exits without closing it
+func Test_Mapped_Crash(t *testing.T) {
+	if dir := os.Getenv("MAPPED_CRASH_DIR"); dir != "" {
+		col, err := large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+		require.NoError(t, err)
+
+		for i := range 500 {
+			_, err := col.Set(i, fmt.Sprint(i))
+			require.NoError(t, err)
+		}
+		require.NoError(t, col.Sync())
+
+		for i := 500; i < 1000; i++ {
+			_, err := col.Set(i, fmt.Sprint(i))
+			require.NoError(t, err)
+		}
+		for i := 0; i < 1000; i += 10 {
+			_, _, err := col.Delete(i)
+			require.NoError(t, err)
+		}
+
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_Mapped_Crash$")
+	cmd.Env = append(os.Environ(), "MAPPED_CRASH_DIR="+dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// Not cleanly closed, so it can not be opened read only
+	_, err = large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int](), large.WithReadOnly())
+	require.ErrorIs(t, err, large.ErrNotClean)
+
+	col, err := large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	defer col.Close()
+
+	require.Equal(t, 900, col.Len())
+	for i := range 1000 {
+		v, ok := col.Get(i)
+		require.Equal(t, i%10 != 0, ok, i)
+		if ok {
+			require.Equal(t, fmt.Sprint(i), v.Value)
+		}
+	}
+
+	_, err = col.Set(0, "back")
+	require.NoError(t, err)
+	v, ok := col.Get(0)
+	require.True(t, ok)
+	require.Equal(t, "back", v.Value)
+}
+
+func Test_Mapped_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	for i := range 10 {
+		_, err := col.Set(i, fmt.Sprint(i))
+		require.NoError(t, err)
+	}
+	size := col.Size()
+	require.NoError(t, col.Close())
+
+	// A dirty index and a record that was only partially written
+	index, err := os.OpenFile(filepath.Join(dir, "index"), os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = index.WriteAt([]byte{1}, 8)
+	require.NoError(t, err)
+	require.NoError(t, index.Close())
+
+	log, err := os.OpenFile(filepath.Join(dir, "log"), os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = log.WriteAt([]byte{1, 2, 3, 4, 8, 0, 0, 0, 100, 0, 0, 0, 0, 0, 0, 0}, int64(size))
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	col, err = large.OpenMapped[int, string](dir, 10, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	defer col.Close()
+
+	require.Equal(t, 10, col.Len())
+	require.Equal(t, size, col.Size())
+	for i := range 10 {
+		v, ok := col.Get(i)
+		require.True(t, ok)
+		require.Equal(t, fmt.Sprint(i), v.Value)
+	}
+}
+
+func Test_Mapped_Compact(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.OpenMapped[int, string](dir, 100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	for round := range 5 {
+		for i := range 100 {
+			_, err := col.Set(i, fmt.Sprint(i, "-", round))
+			require.NoError(t, err)
+		}
+	}
+	for i := 0; i < 100; i += 2 {
+		_, _, err := col.Delete(i)
+		require.NoError(t, err)
+	}
+
+	// Moving the records is postponed while reading
+	size := col.Size()
+	for range col.Read() {
+		require.NoError(t, col.Compact())
+		break
+	}
+	require.Equal(t, size, col.Size())
+
+	require.NoError(t, col.Compact())
+	require.Less(t, col.Size(), size/4)
+	require.Equal(t, 50, col.Len())
+	for i := range 100 {
+		v, ok := col.Get(i)
+		require.Equal(t, i%2 != 0, ok, i)
+		if ok {
+			require.Equal(t, fmt.Sprint(i, "-", 4), v.Value)
+		}
+	}
+
+	// Still complete after reopening
+	_, err = col.Set(0, "back")
+	require.NoError(t, err)
+	require.NoError(t, col.Close())
+	col, err = large.OpenMapped[int, string](dir, 100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	defer col.Close()
+
+	require.Equal(t, 51, col.Len())
+	v, ok := col.Get(0)
+	require.True(t, ok)
+	require.Equal(t, "back", v.Value)
+}
+
+func Test_Mapped_CompactBounded(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.OpenMapped[int, []byte](dir, 100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	defer col.Close()
+
+	// Writes about 10MB, of which only the last 100KB is live
+	value := make([]byte, 1000)
+	for i := range 10_000 {
+		_, err := col.Set(i%100, value)
+		require.NoError(t, err)
+	}
+
+	require.Less(t, col.Size(), uint64(3*1024*1024))
+	stat, err := os.Stat(filepath.Join(dir, "log"))
+	require.NoError(t, err)
+	require.Less(t, stat.Size(), int64(4*1024*1024))
+	require.Equal(t, 100, col.Len())
+}
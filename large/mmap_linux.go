@@ -0,0 +1,46 @@
+//go:build linux
+
+package large
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func mmap(file *os.File, size int, writable bool) ([]byte, error) {
+	prot := syscall.PROT_READ
+	if writable {
+		prot |= syscall.PROT_WRITE
+	}
+
+	return syscall.Mmap(int(file.Fd()), 0, size, prot, syscall.MAP_SHARED)
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
+
+// msync flushes the changes to the mapped data to the file, data has to start at a page boundary
+func msync(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// lockFile takes an advisory lock on the file, shared for readers and exclusive for writers, without waiting
+func lockFile(file *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	return syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+}
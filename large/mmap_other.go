@@ -0,0 +1,24 @@
+//go:build !linux
+
+package large
+
+import (
+	"errors"
+	"os"
+)
+
+func mmap(file *os.File, size int, writable bool) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func munmap(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+func msync(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+func lockFile(file *os.File, exclusive bool) error {
+	return errors.ErrUnsupported
+}
@@ -1,27 +1,46 @@
 package large
 
 import (
+	"errors"
+	"math"
+
 	"github.com/daanv2/go-cache/pkg/options"
 	"github.com/daanv2/go-locks"
 	optimal "github.com/daanv2/go-optimal"
 	"github.com/daanv2/go-optimal/pkg/cpu"
 )
 
-// Options is the base struct for all sets.
+// DefaultShardSize is the default amount of entries a single shard of a [Map] is sized for
+const DefaultShardSize = 64 * 1024
+
+// DefaultArenaSize is the default amount of bytes of a single arena
+const DefaultArenaSize = 4 * 1024 * 1024
+
+// Options is the base struct for all large maps, a bucket is a shard of bucket size entries.
 type Options struct {
 	bucket_size      uint64
 	items_lock       *locks.Pool
 	bucket_amount    uint64
 	bucket_amount_fn func(uint64) uint64
+	arena_size       uint64
+	compact_ratio    float64
+	key_codec        any // A [Codec] matching the key type of the map
+	value_codec      any // A [Codec] matching the value type of the map
+	read_only        bool
 }
 
 // CreateOptions creates a new instance of SetBase with the default bucket size.
 func CreateOptions[T any](opts ...options.Option[Options]) (Options, error) {
 	op := Options{
-		bucket_size: uint64(optimal.SliceSize[T]()),
+		bucket_size: DefaultShardSize,
 		items_lock:  locks.NewPool(),
 		bucket_amount: 0,
 		bucket_amount_fn: nil,
+		arena_size:       DefaultArenaSize,
+		compact_ratio:    0.5,
+		key_codec:        nil,
+		value_codec:      nil,
+		read_only:        false,
 	}
 
 	err := options.Apply(&op, opts...)
@@ -80,3 +99,48 @@ func WithBucketFunction(calc func(capacity uint64) uint64) options.Option[Option
 		option.bucket_amount_fn = calc
 	})
 }
+
+// WithArenaSize sets the amount of bytes each arena pre-allocates, entries larger than it get an arena of their own
+func WithArenaSize(size uint64) options.Option[Options] {
+	return options.NewFunctionE(func(option *Options) error {
+		if size == 0 || size > math.MaxUint32 {
+			return errors.New("arena size has to be between 1 byte and 4GiB")
+		}
+
+		option.arena_size = size
+		return nil
+	})
+}
+
+// WithCompactRatio sets the fraction of the arena bytes of a shard, or of the log of a [Mapped] map, that may be freed before it is compacted
+func WithCompactRatio(ratio float64) options.Option[Options] {
+	return options.NewFunctionE(func(option *Options) error {
+		if ratio <= 0 || ratio > 1 {
+			return errors.New("compact ratio has to be larger than 0 and at most 1")
+		}
+
+		option.compact_ratio = ratio
+		return nil
+	})
+}
+
+// WithKeyCodec sets the codec used to store the keys, defaults to [DefaultCodec]
+func WithKeyCodec[K any](codec Codec[K]) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.key_codec = codec
+	})
+}
+
+// WithValueCodec sets the codec used to store the values, defaults to [DefaultCodec]
+func WithValueCodec[V any](codec Codec[V]) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.value_codec = codec
+	})
+}
+
+// WithReadOnly opens a [Mapped] map without write access, so multiple processes can share it. The files have to be closed cleanly
+func WithReadOnly() options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.read_only = true
+	})
+}
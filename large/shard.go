@@ -0,0 +1,300 @@
+package large
+
+import (
+	"bytes"
+	"encoding/binary"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// header_size is the size of the header in front of every entry: the hash, the key length, and the value length
+const header_size = 16
+
+// location points at an entry, the arena is stored in the upper 32 bits and the offset in the lower 32 bits
+type location uint64
+
+func newLocation(arena int, offset uint64) location {
+	return location(uint64(arena)<<32 | offset)
+}
+
+func (l location) arena() int {
+	return int(l >> 32)
+}
+
+func (l location) offset() uint64 {
+	return uint64(l & 0xFFFFFFFF)
+}
+
+// shard stores entries in append only byte arenas, indexed by the hash of their key.
+// None of the fields hold per entry pointers, so the garbage collector only scans the arenas themselves.
+// Bytes written to an arena are never changed, freed entries are reclaimed by compacting into new arenas.
+type shard struct {
+	arenas        [][]byte
+	index         map[uint64]location   // The first entry with the hash
+	overflow      map[uint64][]location // Further entries with the same hash, only used on collisions
+	amount        int                   // The amount of entries
+	used          uint64                // The amount of bytes written to the arenas
+	live          uint64                // The amount of bytes of reachable entries
+	arena_size    uint64
+	compact_ratio float64
+	lock          sync.RWMutex
+}
+
+func newShard(capacity uint64, base Options) *shard {
+	return &shard{
+		arenas:        nil,
+		index:         make(map[uint64]location, capacity),
+		overflow:      make(map[uint64][]location),
+		amount:        0,
+		used:          0,
+		live:          0,
+		arena_size:    base.arena_size,
+		compact_ratio: base.compact_ratio,
+		lock:          sync.RWMutex{},
+	}
+}
+
+// entry returns the bytes of the entry at the location
+func (s *shard) entry(loc location) []byte {
+	return entryAt(s.arenas, loc)
+}
+
+func entryAt(arenas [][]byte, loc location) []byte {
+	arena := arenas[loc.arena()]
+	offset := loc.offset()
+	klen := uint64(binary.LittleEndian.Uint32(arena[offset+8:]))
+	vlen := uint64(binary.LittleEndian.Uint32(arena[offset+12:]))
+
+	return arena[offset : offset+header_size+klen+vlen]
+}
+
+// find returns the location of the entry with the hash and encoded key, the caller is expected to hold the lock
+func (s *shard) find(hash uint64, key []byte) (location, bool) {
+	loc, ok := s.index[hash]
+	if !ok {
+		return 0, false
+	}
+	if bytes.Equal(entryKey(s.entry(loc)), key) {
+		return loc, true
+	}
+
+	for _, loc := range s.overflow[hash] {
+		if bytes.Equal(entryKey(s.entry(loc)), key) {
+			return loc, true
+		}
+	}
+
+	return 0, false
+}
+
+// get returns the entry with the hash and encoded key
+func (s *shard) get(hash uint64, key []byte) ([]byte, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	loc, ok := s.find(hash, key)
+	if !ok {
+		return nil, false
+	}
+
+	return s.entry(loc), true
+}
+
+// put copies the encoded entry into the arenas, replacing the entry with the same key. Returns true if the entry was added
+func (s *shard) put(entry []byte) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	hash := entryHash(entry)
+	old, found := s.find(hash, entryKey(entry))
+	loc := s.write(entry)
+	s.live += uint64(len(entry))
+
+	if found {
+		s.live -= uint64(len(s.entry(old)))
+		s.relocate(hash, old, loc)
+		s.maybeCompact()
+		return false
+	}
+
+	if _, ok := s.index[hash]; ok {
+		s.overflow[hash] = append(s.overflow[hash], loc)
+	} else {
+		s.index[hash] = loc
+	}
+	s.amount++
+
+	return true
+}
+
+// remove removes the entry with the hash and encoded key, returning its bytes and true if it was found
+func (s *shard) remove(hash uint64, key []byte) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	loc, ok := s.find(hash, key)
+	if !ok {
+		return nil, false
+	}
+
+	// The bytes stay valid after compaction, compaction writes into new arenas
+	entry := s.entry(loc)
+	s.live -= uint64(len(entry))
+	s.amount--
+
+	others := s.overflow[hash]
+	switch {
+	case len(others) == 0:
+		delete(s.index, hash)
+	case s.index[hash] == loc:
+		s.index[hash] = others[len(others)-1]
+		s.setOverflow(hash, others[:len(others)-1])
+	default:
+		s.setOverflow(hash, slices.DeleteFunc(others, func(l location) bool { return l == loc }))
+	}
+
+	s.maybeCompact()
+	return entry, true
+}
+
+// relocate points the index from the old location of an entry to the new one
+func (s *shard) relocate(hash uint64, old, loc location) {
+	if s.index[hash] == old {
+		s.index[hash] = loc
+		return
+	}
+
+	others := s.overflow[hash]
+	for i := range others {
+		if others[i] == old {
+			others[i] = loc
+			return
+		}
+	}
+}
+
+func (s *shard) setOverflow(hash uint64, others []location) {
+	if len(others) == 0 {
+		delete(s.overflow, hash)
+		return
+	}
+
+	s.overflow[hash] = others
+}
+
+// write appends the entry to the current arena, starting a new one if it does not fit
+func (s *shard) write(entry []byte) location {
+	size := uint64(len(entry))
+	last := len(s.arenas) - 1
+	if last < 0 || uint64(cap(s.arenas[last])-len(s.arenas[last])) < size {
+		s.arenas = append(s.arenas, make([]byte, 0, max(s.arena_size, size)))
+		last++
+	}
+
+	offset := uint64(len(s.arenas[last]))
+	s.arenas[last] = append(s.arenas[last], entry...)
+	s.used += size
+
+	return newLocation(last, offset)
+}
+
+// maybeCompact compacts the shard once the freed bytes exceed the compact ratio, and at least an arena worth of bytes
+func (s *shard) maybeCompact() {
+	free := s.used - s.live
+	if free < s.arena_size || float64(free) < float64(s.used)*s.compact_ratio {
+		return
+	}
+
+	s.compact()
+}
+
+// compact copies all reachable entries into new arenas, releasing the old ones
+func (s *shard) compact() {
+	arenas := s.arenas
+	s.arenas = nil
+	s.used = 0
+
+	for hash, loc := range s.index {
+		s.index[hash] = s.write(entryAt(arenas, loc))
+	}
+	for _, others := range s.overflow {
+		for i, loc := range others {
+			others[i] = s.write(entryAt(arenas, loc))
+		}
+	}
+}
+
+// snapshot returns the arenas and the locations of all entries, which stay valid after the lock is released
+func (s *shard) snapshot() shardSnapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	locs := make([]location, 0, s.amount)
+	for _, loc := range s.index {
+		locs = append(locs, loc)
+	}
+	for _, others := range s.overflow {
+		locs = append(locs, others...)
+	}
+
+	return shardSnapshot{
+		arenas:    slices.Clone(s.arenas),
+		locations: locs,
+	}
+}
+
+func (s *shard) len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.amount
+}
+
+func (s *shard) size() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.used
+}
+
+type shardSnapshot struct {
+	arenas    [][]byte
+	locations []location
+}
+
+func (s shardSnapshot) entries() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for _, loc := range s.locations {
+			if !yield(entryAt(s.arenas, loc)) {
+				return
+			}
+		}
+	}
+}
+
+// appendHeader appends room for the header of an entry to dst
+func appendHeader(dst []byte) []byte {
+	return append(dst, make([]byte, header_size)...)
+}
+
+// putHeader fills in the header of the entry, that has its key and value appended
+func putHeader(entry []byte, hash uint64, klen int) {
+	binary.LittleEndian.PutUint64(entry, hash)
+	binary.LittleEndian.PutUint32(entry[8:], uint32(klen))
+	binary.LittleEndian.PutUint32(entry[12:], uint32(len(entry)-header_size-klen))
+}
+
+func entryHash(entry []byte) uint64 {
+	return binary.LittleEndian.Uint64(entry)
+}
+
+func entryKey(entry []byte) []byte {
+	klen := binary.LittleEndian.Uint32(entry[8:])
+	return entry[header_size : header_size+klen]
+}
+
+func entryValue(entry []byte) []byte {
+	klen := binary.LittleEndian.Uint32(entry[8:])
+	return entry[header_size+klen:]
+}
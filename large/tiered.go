@@ -0,0 +1,361 @@
+package large
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/iterators"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+	"github.com/daanv2/go-locks"
+)
+
+// TieredOptions are the options for a [Tiered] map.
+type TieredOptions struct {
+	cold_capacity uint64
+	hot_options   []options.Option[maps.Options]
+	cold_options  []options.Option[Options]
+	on_error      func(err error)
+}
+
+// CreateTieredOptions creates the options for a [Tiered] map, the cold tier is sized for 10 times the hot tier by default.
+func CreateTieredOptions(opts ...options.Option[TieredOptions]) (TieredOptions, error) {
+	op := TieredOptions{
+		cold_capacity: 0,
+		hot_options:   nil,
+		cold_options:  nil,
+		on_error:      nil,
+	}
+
+	err := options.Apply(&op, opts...)
+
+	return op, err
+}
+
+// WithColdCapacity sets the amount of entries the cold tier is initially sized for, it grows when exceeded
+func WithColdCapacity(capacity uint64) options.Option[TieredOptions] {
+	return options.NewFunction(func(option *TieredOptions) {
+		option.cold_capacity = capacity
+	})
+}
+
+// WithHotOptions sets the options of the hot [maps.Bucketted] tier, such as a [maps.WithWeigher] and [maps.WithMaxWeight] to bound it by size
+func WithHotOptions(opts ...options.Option[maps.Options]) options.Option[TieredOptions] {
+	return options.NewFunction(func(option *TieredOptions) {
+		option.hot_options = append(option.hot_options, opts...)
+	})
+}
+
+// WithColdOptions sets the options of the cold [Mapped] tier, such as its codecs
+func WithColdOptions(opts ...options.Option[Options]) options.Option[TieredOptions] {
+	return options.NewFunction(func(option *TieredOptions) {
+		option.cold_options = append(option.cold_options, opts...)
+	})
+}
+
+// WithTierErrorHandler sets the function that receives errors from moving entries between the tiers
+func WithTierErrorHandler(handler func(err error)) options.Option[TieredOptions] {
+	return options.NewFunction(func(option *TieredOptions) {
+		option.on_error = handler
+	})
+}
+
+// Tiered is a two level map, a bounded [maps.Bucketted] hot tier in memory and a [Mapped] cold tier on disk, sharing the same hasher.
+// Entries evicted from the hot tier are demoted to the cold tier by a background demoter, entries found in the cold tier are promoted back to the hot tier.
+// Every entry lives in one tier at a time, changes to a key are serialized by per hash locks.
+type Tiered[K, V comparable] struct {
+	TieredOptions
+	hasher       hash.Hasher[K]
+	capacity     uint64
+	hot          *maps.Bucketted[K, V]
+	cold         *Mapped[K, V]
+	items_lock   *locks.Pool
+	pending      map[K]V // Entries evicted from the hot tier, that are not yet written to the cold tier
+	pending_lock sync.Mutex
+	notify       chan struct{} // Wakes the demoter when entries become pending
+	done         chan struct{}
+	close_once   sync.Once
+	workers      sync.WaitGroup
+}
+
+// NewTiered creates a new Tiered map, with a hot tier bounded to capacity entries and the cold tier stored in the directory.
+// The background demoter is stopped by [Tiered.Close].
+func NewTiered[K, V comparable](capacity uint64, keyhasher hash.Hasher[K], dir string, opts ...options.Option[TieredOptions]) (*Tiered[K, V], error) {
+	base, err := CreateTieredOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if base.cold_capacity == 0 {
+		base.cold_capacity = capacity * 10
+	}
+
+	t := &Tiered[K, V]{
+		TieredOptions: base,
+		hasher:        keyhasher,
+		capacity:      capacity,
+		items_lock:    locks.NewPool(),
+		pending:       make(map[K]V),
+		pending_lock:  sync.Mutex{},
+		notify:        make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	hot := []options.Option[maps.Options]{
+		maps.WithWeigher(maps.WeigherFunc[K, V](func(key K, value V) uint64 { return 1 })),
+		maps.WithMaxWeight(capacity),
+	}
+	hot = append(hot, base.hot_options...)
+	hot = append(hot, maps.WithEvictionHandler(t.evicted))
+
+	t.hot, err = maps.NewBuckettedMap[K, V](capacity, keyhasher, hot...)
+	if err != nil {
+		return nil, err
+	}
+	t.cold, err = OpenMapped[K, V](dir, base.cold_capacity, keyhasher, base.cold_options...)
+	if err != nil {
+		return nil, err
+	}
+
+	t.workers.Add(1)
+	go t.demoter()
+
+	return t, nil
+}
+
+// evicted receives the entries evicted from the hot tier, they stay visible as pending until they are demoted.
+// The hot tier holds its item lock of the key until it returns, so deleting the key from the hot tier waits for it to be pending.
+// A set that started before it became pending leaves it behind, which is dropped by [Tiered.demoteKey] as the hot tier has the key again.
+func (t *Tiered[K, V]) evicted(item maps.KeyValue[K, V]) {
+	t.pending_lock.Lock()
+	t.pending[item.Key] = item.Value
+	t.pending_lock.Unlock()
+
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// demoter writes the pending entries to the cold tier in the background, until the map is closed
+func (t *Tiered[K, V]) demoter() {
+	defer t.workers.Done()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.notify:
+		}
+
+		t.demote()
+	}
+}
+
+// catchUp writes the pending entries on the caller's goroutine once more are pending than the hot tier holds,
+// so their memory stays bounded when the demoter falls behind
+func (t *Tiered[K, V]) catchUp() {
+	t.pending_lock.Lock()
+	backlogged := uint64(len(t.pending)) > t.capacity
+	t.pending_lock.Unlock()
+
+	if backlogged {
+		t.demote()
+	}
+}
+
+func (t *Tiered[K, V]) takePending(key K) (V, bool) {
+	t.pending_lock.Lock()
+	defer t.pending_lock.Unlock()
+
+	v, ok := t.pending[key]
+	delete(t.pending, key)
+	return v, ok
+}
+
+// demote writes the pending entries to the cold tier
+func (t *Tiered[K, V]) demote() {
+	t.pending_lock.Lock()
+	keys := make([]K, 0, len(t.pending))
+	for k := range t.pending {
+		keys = append(keys, k)
+	}
+	t.pending_lock.Unlock()
+
+	for _, key := range keys {
+		t.demoteKey(key)
+	}
+}
+
+func (t *Tiered[K, V]) demoteKey(key K) {
+	lock := t.items_lock.GetLock(t.hasher.Hash(key))
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Changed or deleted while waiting for the lock
+	v, ok := t.takePending(key)
+	if !ok {
+		return
+	}
+	// Set again while it was being evicted, the pending entry is stale
+	if _, ok := t.hot.Get(key); ok {
+		return
+	}
+
+	if _, err := t.cold.Set(key, v); err != nil {
+		t.report(fmt.Errorf("demoting to the cold tier: %w", err))
+	}
+}
+
+func (t *Tiered[K, V]) report(err error) {
+	if t.on_error != nil {
+		t.on_error(err)
+	}
+}
+
+// Get retrieves the value for the specified key, from the hot tier or else from the cold tier, promoting it to the hot tier.
+// Reading from and removing from the cold tier can do disk I/O, as its files are memory mapped, and so can catching up on demotions.
+func (t *Tiered[K, V]) Get(key K) (maps.KeyValue[K, V], bool) {
+	defer t.catchUp()
+
+	lock := t.items_lock.GetLock(t.hasher.Hash(key))
+	lock.Lock()
+	defer lock.Unlock()
+
+	if v, ok := t.hot.Get(key); ok {
+		return v, true
+	}
+
+	t.pending_lock.Lock()
+	pending, ok := t.pending[key]
+	t.pending_lock.Unlock()
+	if ok {
+		return maps.NewKeyValue(t.hasher.Hash(key), key, pending), true
+	}
+
+	v, ok := t.cold.Get(key)
+	if !ok {
+		return v, false
+	}
+
+	t.hot.Set(key, v.Value)
+	if _, _, err := t.cold.Delete(key); err != nil {
+		t.report(fmt.Errorf("promoting from the cold tier: %w", err))
+	}
+
+	return v, true
+}
+
+// Set will add or update the value for the specified key in the hot tier. It returns true if the value was added, false if it was updated.
+// When the demoter falls behind by more than the capacity of the hot tier, the caller writes the pending entries itself.
+func (t *Tiered[K, V]) Set(key K, value V) (bool, error) {
+	defer t.catchUp()
+
+	lock := t.items_lock.GetLock(t.hasher.Hash(key))
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, pending := t.takePending(key)
+	added := t.hot.Set(key, value)
+	_, cold, err := t.cold.Delete(key)
+
+	return added && !pending && !cold, err
+}
+
+// Delete removes the value for the specified key from both tiers. It returns the removed item and true if it was found.
+func (t *Tiered[K, V]) Delete(key K) (maps.KeyValue[K, V], bool, error) {
+	lock := t.items_lock.GetLock(t.hasher.Hash(key))
+	lock.Lock()
+	defer lock.Unlock()
+
+	item, found := t.hot.Delete(key)
+	if v, ok := t.takePending(key); ok && !found {
+		item, found = maps.NewKeyValue(t.hasher.Hash(key), key, v), true
+	}
+
+	cold, ok, err := t.cold.Delete(key)
+	if ok && !found {
+		item, found = cold, true
+	}
+
+	return item, found, err
+}
+
+// Sync writes the pending demotions and syncs the cold tier to disk
+func (t *Tiered[K, V]) Sync() error {
+	t.demote()
+	return t.cold.Sync()
+}
+
+// Close stops the demoter, spills the hot tier into the cold tier and closes it, reopening the directory restores all entries in the cold tier
+func (t *Tiered[K, V]) Close() error {
+	t.close_once.Do(func() { close(t.done) })
+	t.workers.Wait()
+	t.demote()
+
+	var errs []error
+	for item := range t.hot.Read() {
+		if _, err := t.cold.Set(item.Key, item.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, t.cold.Close())
+
+	return errors.Join(errs...)
+}
+
+// Read will return a sequence of all items in both tiers. Items moving between tiers while reading may be seen twice or not at all
+func (t *Tiered[K, V]) Read() iter.Seq[maps.KeyValue[K, V]] {
+	return func(yield func(maps.KeyValue[K, V]) bool) {
+		for item := range t.hot.Read() {
+			if !yield(item) {
+				return
+			}
+		}
+
+		t.pending_lock.Lock()
+		pending := make([]maps.KeyValue[K, V], 0, len(t.pending))
+		for k, v := range t.pending {
+			pending = append(pending, maps.NewKeyValue(t.hasher.Hash(k), k, v))
+		}
+		t.pending_lock.Unlock()
+
+		for _, item := range pending {
+			if !yield(item) {
+				return
+			}
+		}
+
+		for item := range t.cold.Read() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Range will iterate over all items in both tiers
+func (t *Tiered[K, V]) Range(yield func(item maps.KeyValue[K, V]) bool) {
+	iterators.RangeCol(t, yield)
+}
+
+// Hot returns the hot tier
+func (t *Tiered[K, V]) Hot() *maps.Bucketted[K, V] {
+	return t.hot
+}
+
+// Cold returns the cold tier
+func (t *Tiered[K, V]) Cold() *Mapped[K, V] {
+	return t.cold
+}
+
+func (t *Tiered[K, V]) String() string {
+	return fmt.Sprintf("large.Tiered[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (t *Tiered[K, V]) GoString() string {
+	return t.String()
+}
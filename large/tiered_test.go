@@ -0,0 +1,199 @@
+//go:build linux
+
+package large_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/large"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tiered_DemoteAndPromote(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.NewTiered[int, string](100, test_util.CheapIntHasher[int](), dir)
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		added, err := col.Set(i, fmt.Sprint(i))
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+
+	// Most entries have been demoted, once the demoter caught up
+	require.NoError(t, col.Sync())
+	require.LessOrEqual(t, col.Hot().Weight(), uint64(100))
+	require.GreaterOrEqual(t, col.Cold().Len(), 900)
+
+	for i := range 1000 {
+		v, ok := col.Get(i)
+		require.True(t, ok, i)
+		require.Equal(t, fmt.Sprint(i), v.Value)
+	}
+
+	// A promoted entry is served from the hot tier
+	_, ok := col.Get(5)
+	require.True(t, ok)
+	_, ok = col.Hot().Get(5)
+	require.True(t, ok)
+	_, ok = col.Cold().Get(5)
+	require.False(t, ok)
+
+	added, err := col.Set(1, "other")
+	require.NoError(t, err)
+	require.False(t, added)
+	v, ok, err := col.Delete(2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "2", v.Value)
+	_, ok = col.Get(2)
+	require.False(t, ok)
+
+	count := 0
+	for range col.Read() {
+		count++
+	}
+	require.Equal(t, 999, count)
+
+	// Closing spills the hot tier, so everything is back after reopening
+	require.NoError(t, col.Close())
+	col, err = large.NewTiered[int, string](100, test_util.CheapIntHasher[int](), dir)
+	require.NoError(t, err)
+	defer col.Close()
+
+	require.Equal(t, 999, col.Cold().Len())
+	v, ok = col.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "other", v.Value)
+}
+
+func Test_Tiered_Concurrent(t *testing.T) {
+	col, err := large.NewTiered[int, string](100, test_util.CheapIntHasher[int](), t.TempDir())
+	require.NoError(t, err)
+	defer col.Close()
+
+	wg := sync.WaitGroup{}
+	for w := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 500 {
+				key := w*500 + i
+				_, err := col.Set(key, fmt.Sprint(key))
+				require.NoError(t, err)
+			}
+			for i := range 500 {
+				key := w*500 + i
+				v, ok := col.Get(key)
+				require.True(t, ok, key)
+				require.Equal(t, fmt.Sprint(key), v.Value)
+
+				if i%2 == 0 {
+					_, ok, err := col.Delete(key)
+					require.NoError(t, err)
+					require.True(t, ok)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for key := range 2000 {
+		_, ok := col.Get(key)
+		require.Equal(t, key%500%2 != 0, ok, key)
+	}
+}
+
+func Test_Tiered_ChangeDuringEviction(t *testing.T) {
+	// Enough parallelism for a change to land between an entry being evicted and it becoming pending, even on a single cpu
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	col, err := large.NewTiered[int, string](10, test_util.CheapIntHasher[int](), t.TempDir())
+	require.NoError(t, err)
+	defer col.Close()
+
+	workers, keys := 8, 500
+	errs := make(chan error, workers*keys*3)
+	wg := sync.WaitGroup{}
+	for w := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Every set evicts others, so keys are changed while they are being evicted
+			for i := range keys {
+				key := w*keys + i
+				_, err := col.Set(key, "old")
+				errs <- err
+				if i%2 == 0 {
+					_, _, err = col.Delete(key)
+				} else {
+					_, err = col.Set(key, "new")
+				}
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	seen := make(map[int]string)
+	for item := range col.Read() {
+		_, twice := seen[item.Key]
+		require.False(t, twice, item.Key)
+		seen[item.Key] = item.Value
+	}
+	for key := range workers * keys {
+		v, ok := seen[key]
+		if key%keys%2 == 0 {
+			require.False(t, ok, key)
+		} else {
+			require.True(t, ok, key)
+			require.Equal(t, "new", v, key)
+		}
+	}
+}
+
+func Test_Tiered_ChurnBounded(t *testing.T) {
+	dir := t.TempDir()
+	col, err := large.NewTiered[int, string](100, test_util.CheapIntHasher[int](), dir)
+	require.NoError(t, err)
+	defer col.Close()
+
+	value := strings.Repeat("x", 100)
+	for i := range 1000 {
+		_, err := col.Set(i, value)
+		require.NoError(t, err)
+	}
+
+	// Every read promotes a cold entry and demotes another, writing well over the minimum log size
+	for i := range 50_000 {
+		_, ok := col.Get(i * 7 % 1000)
+		require.True(t, ok)
+	}
+	require.NoError(t, col.Sync())
+
+	require.Less(t, col.Cold().Size(), uint64(3*1024*1024))
+	stat, err := os.Stat(filepath.Join(dir, "log"))
+	require.NoError(t, err)
+	require.Less(t, stat.Size(), int64(4*1024*1024))
+	count := 0
+	for range col.Read() {
+		count++
+	}
+	require.Equal(t, 1000, count)
+}
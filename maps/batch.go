@@ -0,0 +1,185 @@
+package maps
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// batch is the items of a batch operation that belong to the same bucket, each hashed once
+type batch[K, V comparable] struct {
+	bucket *GrowableMap[K, V]
+	items  []KeyValue[K, V]
+}
+
+// GetMany returns the items found for the keys, hashing every key once and looking them up per bucket.
+// The items are yielded grouped per bucket, not in the order of the keys.
+func (m *Bucketted[K, V]) GetMany(keys []K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		items := make([]KeyValue[K, V], 0, len(keys))
+		for _, key := range keys {
+			items = append(items, NewKey[K, V](m.hasher.Hash(key), key))
+		}
+
+		groups := m.group(items)
+		found := make([][]KeyValue[K, V], len(groups))
+		m.each(groups, func(i int, b batch[K, V]) {
+			for _, item := range b.items {
+				v, ok := b.bucket.Find(item)
+				if !ok {
+					b.bucket.stats.Miss()
+					continue
+				}
+
+				if v.Absent {
+					b.bucket.stats.NegativeHit()
+					continue
+				}
+
+				b.bucket.stats.Hit()
+				found[i] = append(found[i], v)
+			}
+		})
+
+		for _, items := range found {
+			for _, item := range items {
+				if !yield(item.Key, item.Value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SetMany adds or updates the values of all the items, taking the item locks of every bucket once. It returns the amount of items that were added.
+// When a key is given more than once the last value wins.
+func (m *Bucketted[K, V]) SetMany(items iter.Seq2[K, V]) int {
+	kvs := make([]KeyValue[K, V], 0)
+	for key, value := range items {
+		kvs = append(kvs, NewKeyValue(m.hasher.Hash(key), key, value))
+	}
+
+	groups := m.group(kvs)
+	added := make([]int, len(groups))
+	m.each(groups, func(i int, b batch[K, V]) {
+		unlock := b.bucket.lockMany(b.items)
+		defer unlock()
+
+		for _, item := range b.items {
+			if b.bucket.unsafeUpdateOrAdd(item) {
+				added[i]++
+			}
+		}
+	})
+
+	total := 0
+	for _, n := range added {
+		total += n
+	}
+
+	return total
+}
+
+// DeleteMany removes the values for the keys, taking the item locks of every bucket once. It returns the amount of items that were removed.
+func (m *Bucketted[K, V]) DeleteMany(keys []K) int {
+	items := make([]KeyValue[K, V], 0, len(keys))
+	for _, key := range keys {
+		items = append(items, NewKey[K, V](m.hasher.Hash(key), key))
+	}
+
+	groups := m.group(items)
+	removed := make([]int, len(groups))
+	m.each(groups, func(i int, b batch[K, V]) {
+		unlock := b.bucket.lockMany(b.items)
+		defer unlock()
+
+		for _, item := range b.items {
+			if v, ok := b.bucket.unsafeDelete(item); ok && !v.Absent {
+				removed[i]++
+			}
+		}
+	})
+
+	total := 0
+	for _, n := range removed {
+		total += n
+	}
+
+	return total
+}
+
+// group splits the hashed items per bucket, keeping the order of the items within a bucket
+func (m *Bucketted[K, V]) group(items []KeyValue[K, V]) []batch[K, V] {
+	indexes := make(map[uint64]int)
+	groups := make([]batch[K, V], 0)
+
+	for _, item := range items {
+		index := m.bucketIndex(item)
+		i, ok := indexes[index]
+		if !ok {
+			i = len(groups)
+			indexes[index] = i
+			groups = append(groups, batch[K, V]{bucket: m.sets[index], items: nil})
+		}
+
+		groups[i].items = append(groups[i].items, item)
+	}
+
+	return groups
+}
+
+// each calls fn for every group, spread over the batch workers, see [WithBatchWorkers]
+func (m *Bucketted[K, V]) each(groups []batch[K, V], fn func(i int, b batch[K, V])) {
+	workers := min(m.base.batch_workers, len(groups))
+	if workers <= 1 {
+		for i, b := range groups {
+			fn(i, b)
+		}
+		return
+	}
+
+	wg := &sync.WaitGroup{}
+	process := make(chan int, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range process {
+				fn(i, groups[i])
+			}
+		}()
+	}
+
+	for i := range groups {
+		process <- i
+	}
+	close(process)
+	wg.Wait()
+}
+
+// lockMany locks the item locks of the items, each lock once, returning the function that unlocks them.
+// Locks are taken in the order of the pool, so batches running at the same time can not deadlock each other.
+func (s *GrowableMap[K, V]) lockMany(items []KeyValue[K, V]) func() {
+	stripes := uint64(s.items_lock.Len())
+	hashes := make([]uint64, 0, len(items))
+	for _, item := range items {
+		hashes = append(hashes, item.Hash)
+	}
+	slices.SortFunc(hashes, func(a, b uint64) int { return cmp.Compare(a%stripes, b%stripes) })
+
+	locked := make([]*sync.Mutex, 0, len(hashes))
+	for i, h := range hashes {
+		if i > 0 && h%stripes == hashes[i-1]%stripes {
+			continue
+		}
+
+		locked = append(locked, s.lockItem(h))
+	}
+
+	return func() {
+		for _, lock := range slices.Backward(locked) {
+			lock.Unlock()
+		}
+	}
+}
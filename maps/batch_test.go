@@ -0,0 +1,72 @@
+package maps_test
+
+import (
+	"iter"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func all(values map[int]int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for k, v := range values {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func Test_BuckettedMap_Batch(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		col, err := maps.NewBuckettedMap[int, int](1000, test_util.CheapIntHasher[int](), maps.WithBatchWorkers(workers))
+		require.NoError(t, err)
+
+		values := map[int]int{}
+		for i := range 1000 {
+			values[i] = i * 2
+		}
+		require.Equal(t, 1000, col.SetMany(all(values)))
+		require.Equal(t, 0, col.SetMany(all(map[int]int{1: 1, 2: 2})))
+
+		keys := []int{1, 2, 3, 500, 5000}
+		found := map[int]int{}
+		for k, v := range col.GetMany(keys) {
+			found[k] = v
+		}
+		require.Equal(t, map[int]int{1: 1, 2: 2, 3: 6, 500: 1000}, found)
+
+		require.Equal(t, 3, col.DeleteMany([]int{1, 2, 3, 5000}))
+		_, ok := col.Get(1)
+		require.False(t, ok)
+		v, ok := col.Get(4)
+		require.True(t, ok)
+		require.Equal(t, 8, v.Value)
+	}
+}
+
+func Test_BuckettedMap_Batch_Concurrent(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, int](1000, test_util.CheapIntHasher[int](), maps.WithBatchWorkers(4))
+	require.NoError(t, err)
+
+	// Overlapping batches lock the same stripes, and must not deadlock each other
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := range 50 {
+				values := map[int]int{}
+				for i := range 100 {
+					values[(i*7+w+round)%300] = w
+				}
+				col.SetMany(all(values))
+				col.DeleteMany([]int{w, w + 100})
+			}
+		}()
+	}
+	wg.Wait()
+}
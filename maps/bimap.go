@@ -0,0 +1,230 @@
+package maps
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+	"github.com/daanv2/go-locks"
+)
+
+// ErrValueConflict is returned when a value is already held by another key of a [BiMap]
+var ErrValueConflict = errors.New("value conflict")
+
+// ConflictPolicy decides what a [BiMap] does when a value is set that another key already holds
+type ConflictPolicy int
+
+const (
+	// RejectConflicts leaves the map unchanged and returns an error wrapping [ErrValueConflict]
+	RejectConflicts ConflictPolicy = iota
+	// ReplaceConflicts removes the other key, so the value moves to the new key
+	ReplaceConflicts
+)
+
+// BiMap is a one to one map, every key has a single value and every value a single key.
+// It keeps a forward table from keys to values and a reverse table from values to keys, both [Bucketted] maps.
+// Writers lock the stripes of every key and value they touch, so both tables change together,
+// readers never lock and may see a change in one table before the other.
+type BiMap[K, V comparable] struct {
+	forward     *Bucketted[K, V]
+	reverse     *Bucketted[V, K]
+	keyhasher   hash.Hasher[K]
+	valuehasher hash.Hasher[V]
+	policy      ConflictPolicy
+	items_lock  *locks.Pool
+}
+
+// NewBiMap creates a new BiMap with the specified capacity, hashers, conflict policy and the options of both tables.
+// Weights and eviction are not supported, as a weigher only matches one of the tables and evicting from one would leave the other behind.
+func NewBiMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K], valuehasher hash.Hasher[V], policy ConflictPolicy, opts ...options.Option[Options]) (*BiMap[K, V], error) {
+	if keyhasher == nil || valuehasher == nil {
+		return nil, errors.New("hasher is nil")
+	}
+	if policy != RejectConflicts && policy != ReplaceConflicts {
+		return nil, fmt.Errorf("unknown conflict policy %d", policy)
+	}
+
+	forward, err := NewBuckettedMap[K, V](capacity, keyhasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := forward.base.withoutEviction("bi map"); err != nil {
+		return nil, err
+	}
+	if forward.base.weigher != nil {
+		return nil, errors.New("bi map does not support weights, see WithWeigher")
+	}
+	reverse, err := NewBuckettedMap[V, K](capacity, valuehasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BiMap[K, V]{
+		forward:     forward,
+		reverse:     reverse,
+		keyhasher:   keyhasher,
+		valuehasher: valuehasher,
+		policy:      policy,
+		items_lock:  locks.NewPool(),
+	}, nil
+}
+
+// GetByKey returns the value of the key
+func (m *BiMap[K, V]) GetByKey(key K) (V, bool) {
+	v, ok := m.forward.Get(key)
+	return v.Value, ok
+}
+
+// GetByValue returns the key holding the value
+func (m *BiMap[K, V]) GetByValue(value V) (K, bool) {
+	k, ok := m.reverse.Get(value)
+	return k.Value, ok
+}
+
+// Set binds the key and value, releasing the previous value of the key.
+// If another key holds the value, the [ConflictPolicy] decides if that key is removed or an error wrapping [ErrValueConflict] is returned.
+// It returns true if the key was added, false if it was updated.
+func (m *BiMap[K, V]) Set(key K, value V) (bool, error) {
+	unlock, old_value, has_value, old_key, has_key := m.lock(key, value)
+	defer unlock()
+
+	if has_key && old_key == key {
+		return false, nil
+	}
+	if has_key {
+		if m.policy == RejectConflicts {
+			return false, fmt.Errorf("%w: %v is held by %v", ErrValueConflict, value, old_key)
+		}
+
+		m.forward.Delete(old_key)
+	}
+	if has_value {
+		m.reverse.Delete(old_value)
+	}
+
+	m.forward.Set(key, value)
+	m.reverse.Set(value, key)
+	return !has_value, nil
+}
+
+// DeleteByKey removes the key and its value, returning the value and true if it was found
+func (m *BiMap[K, V]) DeleteByKey(key K) (V, bool) {
+	for {
+		value, ok := m.GetByKey(key)
+		if !ok {
+			return value, false
+		}
+
+		unlock, current, ok, _, _ := m.lock(key, value)
+		if !ok || current != value {
+			// Changed before the locks were taken
+			unlock()
+			continue
+		}
+
+		m.forward.Delete(key)
+		m.reverse.Delete(value)
+		unlock()
+		return value, true
+	}
+}
+
+// DeleteByValue removes the value and its key, returning the key and true if it was found
+func (m *BiMap[K, V]) DeleteByValue(value V) (K, bool) {
+	for {
+		key, ok := m.GetByValue(value)
+		if !ok {
+			return key, false
+		}
+
+		unlock, _, _, current, ok := m.lock(key, value)
+		if !ok || current != key {
+			// Changed before the locks were taken
+			unlock()
+			continue
+		}
+
+		m.forward.Delete(key)
+		m.reverse.Delete(value)
+		unlock()
+		return key, true
+	}
+}
+
+// lock locks the stripes of the key, the value, the current value of the key and the current key of the value.
+// The current ones are read before locking, so it retries until they did not change in between.
+func (m *BiMap[K, V]) lock(key K, value V) (unlock func(), old_value V, has_value bool, old_key K, has_key bool) {
+	for {
+		old_value, has_value = m.GetByKey(key)
+		old_key, has_key = m.GetByValue(value)
+
+		hashes := []uint64{m.keyhasher.Hash(key), m.valuehasher.Hash(value)}
+		if has_value {
+			hashes = append(hashes, m.valuehasher.Hash(old_value))
+		}
+		if has_key {
+			hashes = append(hashes, m.keyhasher.Hash(old_key))
+		}
+		unlock = m.lockHashes(hashes)
+
+		current_value, ok_value := m.GetByKey(key)
+		current_key, ok_key := m.GetByValue(value)
+		if ok_value == has_value && current_value == old_value && ok_key == has_key && current_key == old_key {
+			return unlock, old_value, has_value, old_key, has_key
+		}
+
+		unlock()
+	}
+}
+
+// lockHashes locks the stripes of the hashes, each once and in the order of the pool, returning the function that unlocks them
+func (m *BiMap[K, V]) lockHashes(hashes []uint64) func() {
+	stripes := uint64(m.items_lock.Len())
+	slices.SortFunc(hashes, func(a, b uint64) int { return cmp.Compare(a%stripes, b%stripes) })
+
+	locked := make([]*sync.Mutex, 0, len(hashes))
+	for i, h := range hashes {
+		if i > 0 && h%stripes == hashes[i-1]%stripes {
+			continue
+		}
+
+		lock := m.items_lock.GetLock(h)
+		lock.Lock()
+		locked = append(locked, lock)
+	}
+
+	return func() {
+		for _, lock := range slices.Backward(locked) {
+			lock.Unlock()
+		}
+	}
+}
+
+// KeyValues will return a sequence of all keys and values in the map
+func (m *BiMap[K, V]) KeyValues() iter.Seq2[K, V] {
+	return m.forward.KeyValues()
+}
+
+// Keys will return a sequence of all keys in the map
+func (m *BiMap[K, V]) Keys() iter.Seq[K] {
+	return m.forward.Keys()
+}
+
+// Values will return a sequence of all values in the map
+func (m *BiMap[K, V]) Values() iter.Seq[V] {
+	return m.reverse.Keys()
+}
+
+func (m *BiMap[K, V]) String() string {
+	return fmt.Sprintf("maps.BiMap[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *BiMap[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,130 @@
+package maps_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newBiMap(t *testing.T, policy maps.ConflictPolicy) *maps.BiMap[int, string] {
+	names := hash.NewFunctionHasher(hash.Sha1, func(item string) []byte { return []byte(item) })
+	m, err := maps.NewBiMap[int, string](100, test_util.CheapIntHasher[int](), names, policy)
+	require.NoError(t, err)
+
+	return m
+}
+
+func Test_BiMap(t *testing.T) {
+	m := newBiMap(t, maps.RejectConflicts)
+
+	added, err := m.Set(1, "one")
+	require.NoError(t, err)
+	require.True(t, added)
+	added, err = m.Set(1, "uno")
+	require.NoError(t, err)
+	require.False(t, added)
+
+	_, ok := m.GetByValue("one")
+	require.False(t, ok)
+	k, ok := m.GetByValue("uno")
+	require.True(t, ok)
+	require.Equal(t, 1, k)
+
+	_, err = m.Set(2, "uno")
+	require.ErrorIs(t, err, maps.ErrValueConflict)
+	_, ok = m.GetByKey(2)
+	require.False(t, ok)
+
+	v, ok := m.DeleteByKey(1)
+	require.True(t, ok)
+	require.Equal(t, "uno", v)
+	_, ok = m.GetByValue("uno")
+	require.False(t, ok)
+
+	_, err = m.Set(3, "three")
+	require.NoError(t, err)
+	k, ok = m.DeleteByValue("three")
+	require.True(t, ok)
+	require.Equal(t, 3, k)
+	_, ok = m.GetByKey(3)
+	require.False(t, ok)
+	_, ok = m.DeleteByValue("three")
+	require.False(t, ok)
+}
+
+func Test_BiMap_ReplaceConflicts(t *testing.T) {
+	m := newBiMap(t, maps.ReplaceConflicts)
+
+	_, err := m.Set(1, "one")
+	require.NoError(t, err)
+	added, err := m.Set(2, "one")
+	require.NoError(t, err)
+	require.True(t, added)
+
+	_, ok := m.GetByKey(1)
+	require.False(t, ok)
+	k, ok := m.GetByValue("one")
+	require.True(t, ok)
+	require.Equal(t, 2, k)
+}
+
+func Test_BiMap_RejectsEviction(t *testing.T) {
+	names := hash.NewFunctionHasher(hash.Sha1, func(item string) []byte { return []byte(item) })
+	_, err := maps.NewBiMap[int, string](100, test_util.CheapIntHasher[int](), names, maps.RejectConflicts,
+		maps.WithWeigher(maps.EstimateWeigher[int, string]()), maps.WithMaxWeight(10))
+	require.Error(t, err)
+	_, err = maps.NewBiMap[int, string](100, test_util.CheapIntHasher[int](), names, maps.RejectConflicts,
+		maps.WithWeigher(maps.EstimateWeigher[int, string]()))
+	require.Error(t, err)
+	_, err = maps.NewBiMap[int, string](100, test_util.CheapIntHasher[int](), names, maps.RejectConflicts,
+		maps.WithEvictionHandler(func(maps.KeyValue[int, string]) {}))
+	require.Error(t, err)
+}
+
+func Test_BiMap_Concurrent(t *testing.T) {
+	m := newBiMap(t, maps.ReplaceConflicts)
+
+	errs := make(chan error, 8*500)
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				key, value := (w+i)%20, fmt.Sprint((w*i)%20)
+				switch i % 4 {
+				case 3:
+					m.DeleteByValue(value)
+				default:
+					_, err := m.Set(key, value)
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Both tables describe the same pairs
+	forward := 0
+	for k, v := range m.KeyValues() {
+		forward++
+		back, ok := m.GetByValue(v)
+		require.True(t, ok, v)
+		require.Equal(t, k, back)
+	}
+	reverse := 0
+	for range m.Values() {
+		reverse++
+	}
+	require.Equal(t, forward, reverse)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-cache/pkg/iterators"
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-kit/generics"
 )
 
@@ -40,7 +41,7 @@ func NewBuckettedMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K],
 	}
 
 	for range buckets {
-		s, err := NewGrowableMapFrom[K, V](keyhasher, base)
+		s, err := NewGrowableMapFrom[K, V](keyhasher, base.split(buckets))
 		if err != nil {
 			return nil, err
 		}
@@ -51,17 +52,10 @@ func NewBuckettedMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K],
 	return set, nil
 }
 
-// Get retrieves the value for the specified key from the Bucketted.
+// Get retrieves the value for the specified key from the Bucketted, keys known to be absent are not found. See [Bucketted.Lookup]
 func (m *Bucketted[K, V]) Get(key K) (KeyValue[K, V], bool) {
-	h := m.hasher.Hash(key)
-	kv := NewKey[K, V](h, key)
-	bucket := m.bucketIndex(kv)
-	v, ok := m.sets[bucket].Find(kv)
-	if ok {
-		return v, true
-	}
-
-	return EmptyKeyValue[K, V](), false
+	v, presence := m.Lookup(key)
+	return v, presence == Found
 }
 
 // Set will add or update the value for the specified key in the Bucketted. It returns true if the value was added, false if it was updated.
@@ -72,6 +66,58 @@ func (m *Bucketted[K, V]) Set(key K, item V) bool {
 	return m.sets[bucket].updateOrAdd(kv)
 }
 
+// Delete removes the value for the specified key from the Bucketted, or forgets that it is absent. It returns the removed item and true if it was found.
+func (m *Bucketted[K, V]) Delete(key K) (KeyValue[K, V], bool) {
+	kv, bucket := m.locate(key)
+	v, ok := bucket.delete(kv)
+	if ok && !v.Absent {
+		return v, true
+	}
+
+	return EmptyKeyValue[K, V](), false
+}
+
+// CompareAndDelete removes the value for the specified key if it is equal to old. It returns true if it was removed.
+func (m *Bucketted[K, V]) CompareAndDelete(key K, old V) bool {
+	kv, bucket := m.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	current, ok := bucket.Find(kv)
+	if !ok || current.Absent || current.Value != old {
+		return false
+	}
+
+	_, ok = bucket.unsafeDelete(kv)
+	return ok
+}
+
+// Weight returns the total weight of the items in the Bucketted, always 0 without a [Weigher]
+func (m *Bucketted[K, V]) Weight() uint64 {
+	total := uint64(0)
+	for _, s := range m.sets {
+		total += s.Weight()
+	}
+
+	return total
+}
+
+// Stats returns the statistics of the map summed over its buckets, all zero unless created with [WithStats]
+func (m *Bucketted[K, V]) Stats() stats.Stats {
+	total := stats.Stats{}
+	contention := make([]uint64, 0, len(m.sets))
+	for _, bucket := range m.sets {
+		s := bucket.Stats()
+		contention = append(contention, s.Contention)
+		s.BucketContention = nil
+		total = total.Add(s)
+	}
+	total.BucketContention = contention
+
+	return total
+}
+
 // Append adds all items from the specified Rangeable to the Bucketted.
 func (m *Bucketted[K, V]) Append(other collections.Rangeable[KeyValue[K, V]]) {
 	other.Range(func(item KeyValue[K, V]) bool {
@@ -88,6 +134,12 @@ func (m *Bucketted[K, V]) AppendParralel(other collections.ParralelRangeable[Key
 	})
 }
 
+// locate hashes the key and returns it together with the bucket it belongs to
+func (m *Bucketted[K, V]) locate(key K) (KeyValue[K, V], *GrowableMap[K, V]) {
+	kv := NewKey[K, V](m.hasher.Hash(key), key)
+	return kv, m.sets[m.bucketIndex(kv)]
+}
+
 // bucketIndex returns the index of the bucket that the item should be placed in
 func (s *Bucketted[K, V]) bucketIndex(item KeyValue[K, V]) uint64 {
 	return item.Hash % uint64(len(s.sets))
@@ -172,9 +224,10 @@ func (m *Bucketted[K, V]) Grow(new_capacity uint64) {
 	}
 
 	diff := buckets - current
+	base := m.base.split(buckets)
 	// Add the new buckets
 	for range diff {
-		s, err := NewGrowableMap[K, V](m.hasher)
+		s, err := NewGrowableMapFrom[K, V](m.hasher, base)
 		if err != nil {
 			return
 		}
@@ -195,7 +248,7 @@ func (m *Bucketted[K, V]) Grow(new_capacity uint64) {
 	// Remove the old buckets and rehash the items
 	for i := range current {
 		s := m.sets[i]
-		news, err := NewGrowableMap[K, V](m.hasher)
+		news, err := NewGrowableMapFrom[K, V](m.hasher, base)
 		if err != nil {
 			return
 		}
@@ -0,0 +1,236 @@
+package maps
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"sync/atomic"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// Counter is a map of counters, stored inline as the values of a [Bucketted] map.
+// Changing an existing counter is a lock free atomic update of the stored entry, the per hash item locks are only taken to insert or remove one.
+// Counters that reach zero are removed. [Counter.Reset] and [Counter.Decay] process the buckets in parallel, see [WithBatchWorkers].
+// Eviction and [WithPointerFree] are not supported, as changes to an evicted or copied counter would be lost.
+type Counter[K comparable] struct {
+	data *Bucketted[K, int64]
+}
+
+// counterRemoved marks a counter that is being removed from the map, changes to it go through the lock and a new entry
+const counterRemoved = math.MinInt64
+
+// NewCounter creates a new Counter with the specified capacity, hasher, and options.
+func NewCounter[K comparable](capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*Counter[K], error) {
+	data, err := NewBuckettedMap[K, int64](capacity, keyhasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := data.base.withoutEviction("counter"); err != nil {
+		return nil, err
+	}
+	if data.base.pointer_free {
+		return nil, errors.New("counter does not support pointer free storage, see WithPointerFree")
+	}
+
+	return &Counter[K]{data}, nil
+}
+
+// Get returns the counter of the key, 0 if it has none
+func (c *Counter[K]) Get(key K) int64 {
+	kv, bucket := c.data.locate(key)
+	item := bucket.ref(kv)
+	if item == nil {
+		bucket.stats.Miss()
+		return 0
+	}
+
+	bucket.stats.Hit()
+	if v := atomic.LoadInt64(&item.Value); v != counterRemoved {
+		return v
+	}
+	return 0
+}
+
+// Add adds delta to the counter of the key, returning the new value
+func (c *Counter[K]) Add(key K, delta int64) int64 {
+	kv, bucket := c.data.locate(key)
+	if item := bucket.ref(kv); item != nil {
+		if v, ok := counterAdd(item, delta); ok {
+			if v == 0 {
+				item_lock := bucket.lockItem(kv.Hash)
+				unsafeCounterRemove(bucket, item)
+				item_lock.Unlock()
+			}
+			return v
+		}
+	}
+
+	// Missing or just removed, so a new entry has to be inserted
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	if item := bucket.ref(kv); item != nil {
+		if v, ok := counterAdd(item, delta); ok {
+			if v == 0 {
+				unsafeCounterRemove(bucket, item)
+			}
+			return v
+		}
+	}
+	if delta == 0 {
+		return 0
+	}
+
+	kv.Value = delta
+	bucket.unsafeUpdateOrAdd(kv)
+	return delta
+}
+
+// counterAdd adds delta to the counter of the item, returns false if the counter is being removed
+func counterAdd[K comparable](item *KeyValue[K, int64], delta int64) (int64, bool) {
+	return counterApply(item, func(v int64) int64 { return v + delta })
+}
+
+// counterApply replaces the counter of the item with fn of it, returns false if the counter is being removed
+func counterApply[K comparable](item *KeyValue[K, int64], fn func(v int64) int64) (int64, bool) {
+	for {
+		current := atomic.LoadInt64(&item.Value)
+		if current == counterRemoved {
+			return 0, false
+		}
+
+		v := fn(current)
+		if atomic.CompareAndSwapInt64(&item.Value, current, v) {
+			return v, true
+		}
+	}
+}
+
+// unsafeCounterRemove removes the item if its counter is still at zero, the caller is expected to hold the item lock
+func unsafeCounterRemove[K comparable](bucket *GrowableMap[K, int64], item *KeyValue[K, int64]) {
+	// Marked first, so lock free changes that still hold the item retry with a new entry.
+	// Nothing changes a marked counter, so the entry can be copied while removing it
+	if !atomic.CompareAndSwapInt64(&item.Value, 0, counterRemoved) {
+		return
+	}
+
+	kv := NewKey[K, int64](item.Hash, item.Key)
+	if bucket.ref(kv) == item {
+		bucket.unsafeDelete(kv)
+	}
+}
+
+// Reset removes all counters
+func (c *Counter[K]) Reset() {
+	c.apply(func(int64) int64 { return 0 })
+}
+
+// Decay multiplies all counters by the factor, rounding towards zero. The factor has to be between 0 and 1
+func (c *Counter[K]) Decay(factor float64) error {
+	if factor < 0 || factor > 1 {
+		return fmt.Errorf("decay factor %v is not between 0 and 1", factor)
+	}
+
+	c.apply(func(v int64) int64 { return int64(float64(v) * factor) })
+	return nil
+}
+
+// apply replaces every counter with fn of it, processing the buckets in parallel
+func (c *Counter[K]) apply(fn func(v int64) int64) {
+	groups := make([]batch[K, int64], 0, len(c.data.sets))
+	for _, bucket := range c.data.sets {
+		groups = append(groups, batch[K, int64]{bucket: bucket, items: nil})
+	}
+
+	c.data.each(groups, func(_ int, b batch[K, int64]) {
+		// Collected first, as the bucket changes while applying
+		items := slices.Collect(b.bucket.refs())
+		for _, item := range items {
+			if v, ok := counterApply(item, fn); ok && v == 0 {
+				item_lock := b.bucket.lockItem(item.Hash)
+				unsafeCounterRemove(b.bucket, item)
+				item_lock.Unlock()
+			}
+		}
+	})
+}
+
+// Top returns the n largest counters, largest first
+func (c *Counter[K]) Top(n int) []KeyValue[K, int64] {
+	if n <= 0 {
+		return nil
+	}
+
+	top := make(counterHeap[K], 0, n)
+	for key, v := range c.KeyValues() {
+		item := KeyValue[K, int64]{Key: key, Value: v}
+		switch {
+		case len(top) < n:
+			heap.Push(&top, item)
+		case item.Value > top[0].Value:
+			top[0] = item
+			heap.Fix(&top, 0)
+		}
+	}
+
+	slices.SortFunc(top, func(a, b KeyValue[K, int64]) int { return cmp.Compare(b.Value, a.Value) })
+	return top
+}
+
+// KeyValues will return a sequence of all keys and their counters
+func (c *Counter[K]) KeyValues() iter.Seq2[K, int64] {
+	return func(yield func(K, int64) bool) {
+		for _, bucket := range c.data.sets {
+			for item := range bucket.refs() {
+				// Skip the counters that are being removed
+				v := atomic.LoadInt64(&item.Value)
+				if v == 0 || v == counterRemoved {
+					continue
+				}
+
+				if !yield(item.Key, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the counters summed over its buckets, all zero unless created with [WithStats]
+func (c *Counter[K]) Stats() stats.Stats {
+	return c.data.Stats()
+}
+
+func (c *Counter[K]) String() string {
+	return fmt.Sprintf("maps.Counter[%s]", generics.NameOf[K]())
+}
+
+func (c *Counter[K]) GoString() string {
+	return c.String()
+}
+
+// counterHeap is a min heap of counters, the smallest on top
+type counterHeap[K comparable] []KeyValue[K, int64]
+
+func (h counterHeap[K]) Len() int           { return len(h) }
+func (h counterHeap[K]) Less(i, j int) bool { return h[i].Value < h[j].Value }
+func (h counterHeap[K]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *counterHeap[K]) Push(x any) {
+	*h = append(*h, x.(KeyValue[K, int64]))
+}
+
+func (h *counterHeap[K]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
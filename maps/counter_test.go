@@ -0,0 +1,91 @@
+package maps_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Counter(t *testing.T) {
+	c, err := maps.NewCounter[int](100, test_util.CheapIntHasher[int](), maps.WithBatchWorkers(4))
+	require.NoError(t, err)
+
+	for i := range 50 {
+		c.Add(i, int64(i))
+	}
+	require.Equal(t, int64(10), c.Get(10))
+	require.Equal(t, int64(15), c.Add(10, 5))
+	require.Equal(t, int64(0), c.Add(10, -15))
+	require.Equal(t, int64(0), c.Get(10))
+
+	top := c.Top(3)
+	require.Len(t, top, 3)
+	require.Equal(t, []int{49, 48, 47}, []int{top[0].Key, top[1].Key, top[2].Key})
+	require.Len(t, c.Top(100), 48)
+
+	require.NoError(t, c.Decay(0.5))
+	require.Equal(t, int64(24), c.Get(49))
+	require.Equal(t, int64(0), c.Get(1))
+	require.Error(t, c.Decay(2))
+
+	c.Reset()
+	require.Empty(t, c.Top(10))
+}
+
+func Test_Counter_Concurrent(t *testing.T) {
+	c, err := maps.NewCounter[int](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				c.Add(i%10, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k := range 10 {
+		require.Equal(t, int64(800), c.Get(k))
+	}
+}
+
+func Test_Counter_RejectsEviction(t *testing.T) {
+	_, err := maps.NewCounter[int](100, test_util.CheapIntHasher[int](), maps.WithMaxWeight(10))
+	require.Error(t, err)
+	_, err = maps.NewCounter[int](100, test_util.CheapIntHasher[int](), maps.WithPointerFree())
+	require.Error(t, err)
+}
+
+func Test_Counter_ConcurrentZero(t *testing.T) {
+	c, err := maps.NewCounter[int](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	// Counters keep reaching zero and being removed while others change them
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			delta := int64(1)
+			if w%2 == 0 {
+				delta = -1
+			}
+			for i := range 1000 {
+				c.Add(i%4, delta)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k := range 4 {
+		require.Equal(t, int64(0), c.Get(k))
+	}
+	require.Empty(t, c.Top(10))
+}
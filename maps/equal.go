@@ -0,0 +1,238 @@
+package maps
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/daanv2/go-cache/pkg/constraints"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// equalEntry is a key and value of an [EqualMap]
+type equalEntry[K, V any] struct {
+	key   K
+	value V
+}
+
+// collisions are the entries of an [EqualMap] that share a hash, replaced as a whole on every change so readers never lock
+type collisions[K, V any] struct {
+	entries []equalEntry[K, V]
+}
+
+// identityHasher hashes hashes, which already are
+type identityHasher struct{}
+
+func (identityHasher) Hash(item uint64) uint64 {
+	return item
+}
+
+// EqualMap is a map for keys that can not be compared with ==, such as slices or case insensitive strings.
+// Keys are matched by an equality function, which has to agree with the hasher: equal keys need equal hashes.
+//
+// The entries are kept in a [Bucketted] map by the hash of their key, keys with the same hash share a list that is scanned with the equality function.
+type EqualMap[K, V any] struct {
+	hasher hash.Hasher[K]
+	equal  func(a, b K) bool
+	data   *Bucketted[uint64, *collisions[K, V]]
+}
+
+// NewEqualMap creates a new EqualMap with the specified capacity, hasher, equality function and options.
+// Options that depend on the key and value types, such as [WithWeigher], are not supported,
+// neither is eviction, as it would drop every key sharing the hash.
+func NewEqualMap[K, V any](capacity uint64, keyhasher hash.Hasher[K], equal func(a, b K) bool, opts ...options.Option[Options]) (*EqualMap[K, V], error) {
+	if keyhasher == nil {
+		return nil, errors.New("hasher is nil")
+	}
+	if equal == nil {
+		return nil, errors.New("equal is nil")
+	}
+
+	data, err := NewBuckettedMap[uint64, *collisions[K, V]](capacity, identityHasher{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := data.base.withoutEviction("equal map"); err != nil {
+		return nil, err
+	}
+
+	return &EqualMap[K, V]{
+		hasher: keyhasher,
+		equal:  equal,
+		data:   data,
+	}, nil
+}
+
+// NewEquivalentMap creates a new EqualMap for keys that compare themselves, see [constraints.Equivalent]
+func NewEquivalentMap[K constraints.Equivalent[K], V any](capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*EqualMap[K, V], error) {
+	return NewEqualMap[K, V](capacity, keyhasher, func(a, b K) bool { return a.Equal(b) }, opts...)
+}
+
+// index returns the index of the entry with the key, or -1 if it is not present
+func (m *EqualMap[K, V]) index(entries []equalEntry[K, V], key K) int {
+	for i, e := range entries {
+		if m.equal(e.key, key) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Get retrieves the value for the specified key from the EqualMap.
+func (m *EqualMap[K, V]) Get(key K) (V, bool) {
+	kv, bucket := m.data.locate(m.hasher.Hash(key))
+	current, ok := bucket.Find(kv)
+	if ok {
+		if i := m.index(current.Value.entries, key); i >= 0 {
+			bucket.stats.Hit()
+			return current.Value.entries[i].value, true
+		}
+	}
+
+	bucket.stats.Miss()
+	return generics.Empty[V](), false
+}
+
+// Set will add or update the value for the specified key in the EqualMap, keeping the stored key. It returns true if the value was added, false if it was updated.
+func (m *EqualMap[K, V]) Set(key K, value V) bool {
+	found := m.update(key, func(entry *equalEntry[K, V], _ bool) bool {
+		entry.value = value
+		return true
+	})
+
+	return !found
+}
+
+// Add adds the key and value unless an equal key is present. It returns true if they were added.
+func (m *EqualMap[K, V]) Add(key K, value V) bool {
+	found := m.update(key, func(entry *equalEntry[K, V], found bool) bool {
+		entry.value = value
+		return !found
+	})
+
+	return !found
+}
+
+// Replace stores the key and value, replacing an equal key together with its value. It returns true if they were added, false if they replaced.
+func (m *EqualMap[K, V]) Replace(key K, value V) bool {
+	found := m.update(key, func(entry *equalEntry[K, V], _ bool) bool {
+		entry.key = key
+		entry.value = value
+		return true
+	})
+
+	return !found
+}
+
+// update changes the entry of the key while holding its item lock, returning if an equal key was found.
+// The entry is a new one holding the key if none was found, apply returns false to leave the map unchanged.
+func (m *EqualMap[K, V]) update(key K, apply func(entry *equalEntry[K, V], found bool) bool) bool {
+	kv, bucket := m.data.locate(m.hasher.Hash(key))
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	var entries []equalEntry[K, V]
+	if current, ok := bucket.Find(kv); ok {
+		entries = current.Value.entries
+	}
+
+	i := m.index(entries, key)
+	entry := equalEntry[K, V]{key: key}
+	if i >= 0 {
+		entry = entries[i]
+	}
+	if !apply(&entry, i >= 0) {
+		return i >= 0
+	}
+
+	next := make([]equalEntry[K, V], len(entries), len(entries)+1)
+	copy(next, entries)
+	if i >= 0 {
+		next[i] = entry
+	} else {
+		next = append(next, entry)
+	}
+
+	kv.Value = &collisions[K, V]{next}
+	bucket.unsafeUpdateOrAdd(kv)
+	return i >= 0
+}
+
+// Delete removes the value for the specified key from the EqualMap. It returns the removed value and true if it was found.
+func (m *EqualMap[K, V]) Delete(key K) (V, bool) {
+	kv, bucket := m.data.locate(m.hasher.Hash(key))
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	current, ok := bucket.Find(kv)
+	if !ok {
+		return generics.Empty[V](), false
+	}
+
+	entries := current.Value.entries
+	i := m.index(entries, key)
+	if i < 0 {
+		return generics.Empty[V](), false
+	}
+
+	if len(entries) == 1 {
+		bucket.unsafeDelete(kv)
+		return entries[i].value, true
+	}
+
+	next := make([]equalEntry[K, V], 0, len(entries)-1)
+	next = append(next, entries[:i]...)
+	next = append(next, entries[i+1:]...)
+	kv.Value = &collisions[K, V]{next}
+	bucket.unsafeUpdateOrAdd(kv)
+
+	return entries[i].value, true
+}
+
+// KeyValues will return a sequence of all keys and values in the map
+func (m *EqualMap[K, V]) KeyValues() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for item := range m.data.Read() {
+			for _, e := range item.Value.entries {
+				if !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Keys will return a sequence of all keys in the map
+func (m *EqualMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.KeyValues() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values will return a sequence of all values in the map
+func (m *EqualMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range m.KeyValues() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (m *EqualMap[K, V]) String() string {
+	return fmt.Sprintf("maps.EqualMap[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *EqualMap[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,91 @@
+package maps_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/stretchr/testify/require"
+)
+
+// lengthHasher hashes slices by their length, so most keys collide
+type lengthHasher struct{}
+
+func (lengthHasher) Hash(item []int) uint64 {
+	return uint64(len(item))
+}
+
+// foldedKey is a case insensitive string
+type foldedKey string
+
+func (k foldedKey) Equal(other foldedKey) bool {
+	return strings.EqualFold(string(k), string(other))
+}
+
+func Test_EqualMap_SliceKeys(t *testing.T) {
+	m, err := maps.NewEqualMap[[]int, string](10, lengthHasher{}, slices.Equal[[]int])
+	require.NoError(t, err)
+
+	require.True(t, m.Set([]int{1, 2}, "a"))
+	require.True(t, m.Set([]int{2, 1}, "b"))
+	require.True(t, m.Set([]int{3}, "c"))
+	require.False(t, m.Set([]int{1, 2}, "A"))
+
+	v, ok := m.Get([]int{1, 2})
+	require.True(t, ok)
+	require.Equal(t, "A", v)
+	v, ok = m.Get([]int{2, 1})
+	require.True(t, ok)
+	require.Equal(t, "b", v)
+	_, ok = m.Get([]int{1, 3})
+	require.False(t, ok)
+
+	count := 0
+	for range m.KeyValues() {
+		count++
+	}
+	require.Equal(t, 3, count)
+
+	v, ok = m.Delete([]int{1, 2})
+	require.True(t, ok)
+	require.Equal(t, "A", v)
+	_, ok = m.Delete([]int{1, 2})
+	require.False(t, ok)
+	_, ok = m.Get([]int{2, 1})
+	require.True(t, ok)
+
+	_, ok = m.Delete([]int{2, 1})
+	require.True(t, ok)
+	require.Equal(t, []string{"c"}, slices.Collect(m.Values()))
+}
+
+func Test_EqualMap_Equivalent(t *testing.T) {
+	hasher := hash.NewFunctionHasher(hash.Sha1, func(item foldedKey) []byte {
+		return []byte(strings.ToLower(string(item)))
+	})
+	m, err := maps.NewEquivalentMap[foldedKey, int](10, hasher)
+	require.NoError(t, err)
+
+	require.True(t, m.Set("Hello", 1))
+	require.False(t, m.Set("HELLO", 2))
+
+	v, ok := m.Get("hello")
+	require.True(t, ok)
+	require.Equal(t, 2, v)
+	require.Equal(t, []foldedKey{"Hello"}, slices.Collect(m.Keys()))
+
+	// Add leaves an equal key alone, Replace also swaps the stored key
+	require.False(t, m.Add("hello", 3))
+	require.True(t, m.Add("World", 3))
+	require.False(t, m.Replace("hello", 4))
+	v, _ = m.Get("HELLO")
+	require.Equal(t, 4, v)
+	require.ElementsMatch(t, []foldedKey{"hello", "World"}, slices.Collect(m.Keys()))
+
+	_, err = maps.NewEqualMap[[]int, int](10, nil, slices.Equal[[]int])
+	require.Error(t, err)
+	_, err = maps.NewEqualMap[[]int, int](10, lengthHasher{}, slices.Equal[[]int], maps.WithMaxWeight(10))
+	require.Error(t, err)
+}
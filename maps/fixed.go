@@ -3,58 +3,257 @@ package maps
 import (
 	"iter"
 	"sync"
+	"sync/atomic"
+
+	"github.com/daanv2/go-cache/pkg/arena"
+	"github.com/daanv2/go-cache/pkg/bloomfilters"
+	"github.com/daanv2/go-cache/pkg/probing"
 )
 
-// Fixed is a fixed size slice, that can be used to store a fixed amount of items
+// Fixed is a fixed size slice, that can be used to store a fixed amount of items.
+//
+// Every slot holds an atomic pointer to an immutable item, readers load the pointers and never block,
+// writers replace the pointers while holding the lock, so they are serialized amongst each other.
+// Slots are probed in groups of 8 through SwissTable style control bytes, see [probing], so lookups for missing keys stop early.
+// Items never move once placed, deleted slots are marked and reused, which keeps the lock free reads correct.
+//
+// When created for a pointer free map, see [WithPointerFree], the slots hold handles of items copied into an arena instead.
 type Fixed[K, V comparable] struct {
-	amount uint64
-	items  []KeyValue[K, V] // The items in the slice
-	lock   sync.RWMutex     // The lock to protect the slice
+	hashrange *bloomfilters.Atomic             // The hashes that have been placed in the slice, to skip it when looking for others
+	ctrl      []atomic.Uint64                  // The control bytes of the slots, grouped per 8
+	items     []atomic.Pointer[KeyValue[K, V]] // The items in the slice, nil is an empty slot
+	packer    *packer[K, V]                    // Set for pointer free storage, items is unused then
+	table     *atomic.Pointer[packedTable]     // The handles and arena of pointer free storage
+	lock      sync.Mutex                       // The lock to serialize writers
 }
 
 func NewFixed[K, V comparable](amount uint64) Fixed[K, V] {
 	return Fixed[K, V]{
-		amount: amount,
-		items:  make([]KeyValue[K, V], amount),
-		lock:   sync.RWMutex{},
+		hashrange: bloomfilters.NewAtomic(amount),
+		ctrl:      newControl(amount),
+		items:     make([]atomic.Pointer[KeyValue[K, V]], amount),
+		packer:    nil,
+		table:     nil,
+		lock:      sync.Mutex{},
+	}
+}
+
+// newPackedFixed creates a Fixed that stores its items in an arena through the packer
+func newPackedFixed[K, V comparable](amount uint64, p *packer[K, V]) Fixed[K, V] {
+	table := &atomic.Pointer[packedTable]{}
+	table.Store(newPackedTable(amount, packedChunkSize(amount)))
+
+	return Fixed[K, V]{
+		hashrange: bloomfilters.NewAtomic(amount),
+		ctrl:      newControl(amount),
+		items:     nil,
+		packer:    p,
+		table:     table,
+		lock:      sync.Mutex{},
 	}
 }
 
+func newControl(amount uint64) []atomic.Uint64 {
+	control := probing.NewControl(amount)
+	ctrl := make([]atomic.Uint64, len(control))
+	for i, word := range control {
+		ctrl[i].Store(word)
+	}
+
+	return ctrl
+}
+
+// packedChunkSize returns the size of the arena chunks for amount items, assuming small keys and values
+func packedChunkSize(amount uint64) int {
+	return int(min(amount*(packed_header_size+32), 64*1024))
+}
+
 func (s *Fixed[K, V]) Cap() int {
-	return cap(s.items)
+	return s.Len()
 }
 
 func (s *Fixed[K, V]) Len() int {
+	if s.packer != nil {
+		return len(s.table.Load().handles)
+	}
+
 	return len(s.items)
 }
 
-func (s *Fixed[K, V]) index(item KeyValue[K, V]) uint64 {
-	return item.Hash % s.amount
+// HasHash returns false if no item with the hash has ever been placed in the slice
+func (s *Fixed[K, V]) HasHash(hash uint64) bool {
+	return s.hashrange.Has(hash)
 }
 
+// Get returns the item with the same key, without taking any locks
 func (s *Fixed[K, V]) Get(item KeyValue[K, V]) (KeyValue[K, V], bool) {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
 	return s.get(item)
 }
 
 func (s *Fixed[K, V]) get(item KeyValue[K, V]) (KeyValue[K, V], bool) {
-	sindex := s.index(item)
+	i, v := s.find(item)
+	if i < 0 {
+		return item, false
+	}
+
+	return v, true
+}
+
+// load returns the item in the slot, and false if the slot is empty
+func (s *Fixed[K, V]) load(i uint64) (KeyValue[K, V], bool) {
+	if s.packer != nil {
+		table := s.table.Load()
+		h := arena.Handle(table.handles[i].Load())
+		if h == 0 {
+			return KeyValue[K, V]{}, false
+		}
+
+		return s.packer.read(table.arena, h), true
+	}
+
+	v := s.items[i].Load()
+	if v == nil {
+		return KeyValue[K, V]{}, false
+	}
+
+	return *v, true
+}
+
+// store places the item in the slot, the caller is expected to hold the lock
+func (s *Fixed[K, V]) store(i uint64, item KeyValue[K, V]) {
+	if s.packer == nil {
+		s.items[i].Store(&item)
+		return
+	}
+
+	table := s.table.Load()
+	old := arena.Handle(table.handles[i].Load())
+	table.handles[i].Store(uint64(s.packer.write(table.arena, item)))
+	s.release(table, old)
+}
+
+// release accounts for the entry that is no longer referenced, compacting the arena once half of it is unused
+func (s *Fixed[K, V]) release(table *packedTable, h arena.Handle) {
+	if h == 0 {
+		return
+	}
+
+	table.garbage += s.packer.size(table.arena, h)
+	if table.garbage < uint64(packedChunkSize(uint64(len(table.handles)))) || table.garbage*2 < table.arena.Size() {
+		return
+	}
+
+	// Readers still using the old table keep seeing its items, which were current when they loaded it
+	compacted := newPackedTable(uint64(len(table.handles)), packedChunkSize(uint64(len(table.handles))))
+	for i := range table.handles {
+		if h := arena.Handle(table.handles[i].Load()); h != 0 {
+			compacted.handles[i].Store(uint64(s.packer.write(compacted.arena, s.packer.read(table.arena, h))))
+		}
+	}
+	s.table.Store(compacted)
+}
+
+// find returns the slot index and item with the same key, or -1 if it is not present
+func (s *Fixed[K, V]) find(item KeyValue[K, V]) (int, KeyValue[K, V]) {
+	i := s.index(item)
+	if i < 0 {
+		return -1, item
+	}
+
+	v, ok := s.load(uint64(i))
+	if !ok || !sameKey(item, v) {
+		return -1, item
+	}
+
+	return i, v
+}
+
+// index returns the slot index of the item with the same key, or -1 if it is not present
+func (s *Fixed[K, V]) index(item KeyValue[K, V]) int {
+	h2 := probing.H2(item.Hash)
+	groups := uint64(len(s.ctrl))
+	g := probing.Start(item.Hash, groups)
+
+	for range groups {
+		word := s.ctrl[g].Load()
+		for m := probing.MatchH2(word, h2); m.Any(); m = m.Next() {
+			i := g*probing.GroupSize + m.First()
+			if s.matches(i, item) {
+				return int(i)
+			}
+		}
+
+		// The item would have been placed in this group if it existed
+		if probing.MatchEmpty(word).Any() {
+			return -1
+		}
 
-	for _, v := range s.items[sindex:] {
-		if sameKey(item, v) {
-			return v, true
+		g++
+		if g == groups {
+			g = 0
 		}
 	}
 
-	for _, v := range s.items[:sindex] {
-		if sameKey(item, v) {
-			return v, true
+	return -1
+}
+
+// matches returns true if the slot holds an item with the same key, without copying the value of the item
+func (s *Fixed[K, V]) matches(i uint64, item KeyValue[K, V]) bool {
+	if s.packer != nil {
+		v, ok := s.load(i)
+		return ok && sameKey(item, v)
+	}
+
+	v := s.items[i].Load()
+	return v != nil && sameKey(item, KeyValue[K, V]{Hash: v.Hash, Key: v.Key})
+}
+
+// ref returns the stored item with the same key, or nil if it is not present. Not supported for pointer free storage.
+// Only its value may be changed, and only through atomic operations by callers that never copy the item.
+func (s *Fixed[K, V]) ref(item KeyValue[K, V]) *KeyValue[K, V] {
+	i := s.index(item)
+	if i < 0 {
+		return nil
+	}
+
+	// The slot might have been reused for another key since
+	v := s.items[i].Load()
+	if v == nil || !sameKey(item, KeyValue[K, V]{Hash: v.Hash, Key: v.Key}) {
+		return nil
+	}
+
+	return v
+}
+
+// refs returns a sequence of the stored items, see [Fixed.ref]
+func (s *Fixed[K, V]) refs() iter.Seq[*KeyValue[K, V]] {
+	return func(yield func(*KeyValue[K, V]) bool) {
+		for i := range s.items {
+			v := s.items[i].Load()
+			if v == nil {
+				continue
+			}
+
+			if !yield(v) {
+				return
+			}
 		}
 	}
+}
+
+// clear marks the slot as deleted and removes its item, the caller is expected to hold the lock
+func (s *Fixed[K, V]) clear(i uint64) {
+	g, slot := i/probing.GroupSize, i%probing.GroupSize
+	s.ctrl[g].Store(probing.Set(s.ctrl[g].Load(), slot, probing.Deleted))
+
+	if s.packer == nil {
+		s.items[i].Store(nil)
+		return
+	}
 
-	return item, false
+	table := s.table.Load()
+	old := arena.Handle(table.handles[i].Swap(0))
+	s.release(table, old)
 }
 
 // Fixed Add the given item to the set, if equivalant item was overriden, or empty space filled, true is returned
@@ -66,21 +265,28 @@ func (s *Fixed[K, V]) Set(item KeyValue[K, V]) bool {
 }
 
 func (s *Fixed[K, V]) set(item KeyValue[K, V]) bool {
-	sindex := s.index(item)
+	if i, _ := s.find(item); i >= 0 {
+		s.store(uint64(i), item)
+		return true
+	}
+
+	groups := uint64(len(s.ctrl))
+	g := probing.Start(item.Hash, groups)
 
-	sub := s.items[sindex:]
-	for i, spot := range sub {
-		if spot.IsEmpty() || sameKey(item, spot) {
-			sub[i] = item
+	for range groups {
+		word := s.ctrl[g].Load()
+		if m := probing.MatchFree(word); m.Any() {
+			slot := m.First()
+			// Publish the hash and item before the control byte, so readers that match it find the item
+			s.hashrange.Set(item.Hash)
+			s.store(g*probing.GroupSize+slot, item)
+			s.ctrl[g].Store(probing.Set(word, slot, probing.H2(item.Hash)))
 			return true
 		}
-	}
 
-	sub = s.items[:sindex]
-	for i, spot := range sub {
-		if spot.IsEmpty() || sameKey(item, spot) {
-			sub[i] = item
-			return true
+		g++
+		if g == groups {
+			g = 0
 		}
 	}
 
@@ -91,37 +297,52 @@ func (s *Fixed[K, V]) Update(item KeyValue[K, V]) bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	return s.update(item)
+	_, ok := s.replace(item)
+	return ok
 }
 
-func (s *Fixed[K, V]) update(item KeyValue[K, V]) bool {
-	sindex := s.index(item)
-	sub := s.items[sindex:]
-	for i, v := range sub {
-		if sameKey(item, v) {
-				sub[i] = item
-				return true
-		}
+// Replace overrides the item with the same key, returning the item it replaced and true if it was found
+func (s *Fixed[K, V]) Replace(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.replace(item)
+}
+
+func (s *Fixed[K, V]) replace(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	i, v := s.find(item)
+	if i < 0 {
+		return item, false
 	}
 
-	sub = s.items[:sindex]
-	for i, v := range sub {
-		if sameKey(item, v) {
-				sub[i] = item
-				return true
-		}
+	s.store(uint64(i), item)
+	return v, true
+}
+
+// Delete removes the item with the same key from the slice, returning the removed item and true if it was found
+func (s *Fixed[K, V]) Delete(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.delete(item)
+}
+
+func (s *Fixed[K, V]) delete(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	i, v := s.find(item)
+	if i < 0 {
+		return item, false
 	}
 
-	return false
+	s.clear(uint64(i))
+	return v, true
 }
 
+// Read returns a sequence of the items, without taking any locks. Items changed while reading may or may not be seen
 func (s *Fixed[K, V]) Read() iter.Seq[KeyValue[K, V]] {
 	return func(yield func(KeyValue[K, V]) bool) {
-		s.lock.RLock()
-		defer s.lock.RUnlock()
-
-		for _, v := range s.items {
-			if v.IsEmpty() {
+		for i := range uint64(s.Len()) {
+			v, ok := s.load(i)
+			if !ok {
 				continue
 			}
 
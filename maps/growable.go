@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-cache/pkg/iterators"
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-kit/generics"
 	optimal "github.com/daanv2/go-optimal"
 )
@@ -17,8 +20,13 @@ import (
 type GrowableMap[K, V comparable] struct {
 	Options
 	hasher      hash.Hasher[K]
-	buckets     []*Fixed[K, V]
-	bucket_lock sync.RWMutex
+	buckets     atomic.Pointer[[]*Fixed[K, V]] // Replaced as a whole when the chain changes, so readers never lock
+	bucket_lock sync.Mutex                     // Serializes changes to the chain
+	weigher     Weigher[K, V]
+	weight      atomic.Int64
+	on_evict    func(item KeyValue[K, V])
+	packer      *packer[K, V]   // Set when the entries are stored without pointers
+	stats       *stats.Counters // Set when stats are recorded, see [WithStats]
 }
 
 // NewGrowableMap creates a new instance of GrowableMap with the provided hasher and options.
@@ -46,12 +54,52 @@ func NewGrowableMapFrom[K, V comparable](hasher hash.Hasher[K], base Options) (*
 		return nil, errors.New("bucket size is too small <= 1")
 	}
 
-	return &GrowableMap[K, V]{
+	var weigher Weigher[K, V]
+	if base.weigher != nil {
+		w, ok := base.weigher.(Weigher[K, V])
+		if !ok {
+			return nil, fmt.Errorf("weigher %T does not match the map types %s,%s", base.weigher, generics.NameOf[K](), generics.NameOf[V]())
+		}
+		weigher = w
+	}
+	if base.max_weight > 0 && weigher == nil {
+		return nil, errors.New("max weight requires a weigher")
+	}
+
+	var on_evict func(item KeyValue[K, V])
+	if base.on_evict != nil {
+		h, ok := base.on_evict.(func(item KeyValue[K, V]))
+		if !ok {
+			return nil, fmt.Errorf("eviction handler %T does not match the map types %s,%s", base.on_evict, generics.NameOf[K](), generics.NameOf[V]())
+		}
+		on_evict = h
+	}
+
+	var p *packer[K, V]
+	if base.pointer_free {
+		var err error
+		if p, err = newPacker[K, V](); err != nil {
+			return nil, err
+		}
+	}
+
+	var counters *stats.Counters
+	if base.stats {
+		counters = stats.NewCounters()
+	}
+
+	s := &GrowableMap[K, V]{
 		Options:     base,
 		hasher:      hasher,
-		buckets:     make([]*Fixed[K, V], 0),
-		bucket_lock: sync.RWMutex{},
-	}, nil
+		bucket_lock: sync.Mutex{},
+		weigher:     weigher,
+		on_evict:    on_evict,
+		packer:      p,
+		stats:       counters,
+	}
+	s.buckets.Store(&[]*Fixed[K, V]{})
+
+	return s, nil
 }
 
 func (s *GrowableMap[K, V]) NewKeyValue(key K, value V) KeyValue[K, V] {
@@ -80,79 +128,265 @@ func (s *GrowableMap[K, V]) GetOrAddKV(item KeyValue[K, V]) (KeyValue[K, V], boo
 
 // GetOrAdd returns the item if it exists in the set, otherwise it adds it and returns it.
 func (s *GrowableMap[K, V]) getOrAdd(item KeyValue[K, V]) (KeyValue[K, V], bool) {
-	item_lock := s.items_lock.GetLock(item.Hash)
-
-	item_lock.Lock()
+	item_lock := s.lockItem(item.Hash)
 	defer item_lock.Unlock()
 
 	// Find it
 	v, ok := s.Find(item)
-	if ok {
+	if ok && !v.Absent {
+		s.stats.Hit()
 		return v, false
 	}
 
-	s.set(item)
+	s.stats.Miss()
+	if ok {
+		// Replaces the negative entry
+		s.unsafeUpdateOrAdd(item)
+	} else {
+		s.set(item)
+	}
 	return item, true
 }
 
+// lockItem locks the item lock of the hash, recording if it had to wait for it
+func (s *GrowableMap[K, V]) lockItem(hash uint64) *sync.Mutex {
+	if s.items_lock_stats != nil {
+		item_lock, waited := s.items_lock_stats.Lock(hash)
+		if waited {
+			s.stats.Contended()
+		}
+
+		return item_lock
+	}
+
+	item_lock := s.items_lock.GetLock(hash)
+	if !item_lock.TryLock() {
+		s.stats.Contended()
+		item_lock.Lock()
+	}
+
+	return item_lock
+}
+
 // updateOrAdd TODO. return true if it had to add it instead of update
 func (s *GrowableMap[K, V]) updateOrAdd(item KeyValue[K, V]) bool {
-	item_lock := s.items_lock.GetLock(item.Hash)
-
-	item_lock.Lock()
+	item_lock := s.lockItem(item.Hash)
 	defer item_lock.Unlock()
 
-	// Find it
-	ok := s.updateIf(item)
+	return s.unsafeUpdateOrAdd(item)
+}
+
+// unsafeUpdateOrAdd is [GrowableMap.updateOrAdd] without taking the item lock, the caller is expected to hold it
+func (s *GrowableMap[K, V]) unsafeUpdateOrAdd(item KeyValue[K, V]) bool {
+	// Find it, replacing a negative entry counts as adding
+	old, ok := s.updateIf(item)
 	if ok {
-		return false
+		return old.Absent
 	}
 
 	s.set(item)
 	return true
 }
 
-func (s *GrowableMap[K, V]) updateIf(item KeyValue[K, V]) bool {
-	s.bucket_lock.RLock()
-	defer s.bucket_lock.RUnlock()
+// Delete removes the item with the given key from the set. Returns the removed item and true if it was found.
+func (s *GrowableMap[K, V]) Delete(key K) (KeyValue[K, V], bool) {
+	return s.delete(NewKey[K, V](s.hasher.Hash(key), key))
+}
+
+// DeleteKV removes the item with the same key from the set. Returns the removed item and true if it was found.
+func (s *GrowableMap[K, V]) DeleteKV(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	return s.delete(item)
+}
+
+func (s *GrowableMap[K, V]) delete(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	item_lock := s.lockItem(item.Hash)
+	defer item_lock.Unlock()
+
+	return s.unsafeDelete(item)
+}
+
+// unsafeDelete is [GrowableMap.delete] without taking the item lock, the caller is expected to hold it
+func (s *GrowableMap[K, V]) unsafeDelete(item KeyValue[K, V]) (KeyValue[K, V], bool) {
+	for _, bucket := range s.chain() {
+		if !bucket.HasHash(item.Hash) {
+			continue
+		}
+
+		v, ok := bucket.Delete(item)
+		if ok {
+			s.addWeight(v, -1)
+			return v, true
+		}
+	}
 
+	return item, false
+}
+
+// updateIf replaces the item with the same key, returning the item it replaced and true if it was found
+func (s *GrowableMap[K, V]) updateIf(item KeyValue[K, V]) (KeyValue[K, V], bool) {
 	// Try to find it
-	for _, bucket := range s.buckets {
-		ok := bucket.Update(item)
+	for _, bucket := range s.chain() {
+		if !bucket.HasHash(item.Hash) {
+			continue
+		}
+
+		old, ok := bucket.Replace(item)
 		if ok {
-			return true
+			s.addWeight(old, -1)
+			s.addWeight(item, 1)
+			// Growing the value can push the weight over the budget as well
+			if s.max_weight > 0 && s.weigher.Weigh(item.Key, item.Value) > s.weigher.Weigh(old.Key, old.Value) {
+				s.evict(item)
+			}
+			return old, true
 		}
 	}
 
-	return false
+	return item, false
 }
 
 func (s *GrowableMap[K, V]) set(item KeyValue[K, V]) {
+	defer s.evict(item)
+
 	s.bucket_lock.Lock()
 	defer s.bucket_lock.Unlock()
 
-	l := len(s.buckets) - 1
+	s.addWeight(item, 1)
+
+	buckets := s.chain()
+	l := len(buckets) - 1
 	if l >= 0 {
-		if s.buckets[l].Set(item) {
+		if buckets[l].Set(item) {
 			return
 		}
 	}
 
 	for {
-		b := NewFixed[K, V](s.Options.bucket_size)
-		s.buckets = append(s.buckets, &b)
-		if s.buckets[len(s.buckets)-1].Set(item) {
+		b := s.newBucket()
+		ok := b.Set(item)
+		// Copy the chain, readers might still be walking the old one
+		buckets = append(buckets[:len(buckets):len(buckets)], &b)
+		s.buckets.Store(&buckets)
+		if ok {
 			return
 		}
 	}
 }
 
-func (s *GrowableMap[K, V]) Find(item KeyValue[K, V]) (KeyValue[K, V], bool) {
-	s.bucket_lock.RLock()
-	defer s.bucket_lock.RUnlock()
+// newBucket creates a bucket, storing its entries without pointers if the map does
+func (s *GrowableMap[K, V]) newBucket() Fixed[K, V] {
+	if s.packer != nil {
+		return newPackedFixed(s.Options.bucket_size, s.packer)
+	}
+
+	return NewFixed[K, V](s.Options.bucket_size)
+}
 
+// chain returns the current chain of buckets, which is never modified in place
+func (s *GrowableMap[K, V]) chain() []*Fixed[K, V] {
+	return *s.buckets.Load()
+}
+
+// Weight returns the total weight of the items in the set, always 0 without a [Weigher]
+func (s *GrowableMap[K, V]) Weight() uint64 {
+	return uint64(max(s.weight.Load(), 0))
+}
+
+// addWeight adds the weight of the item times sign to the total
+func (s *GrowableMap[K, V]) addWeight(item KeyValue[K, V], sign int64) {
+	if s.weigher == nil {
+		return
+	}
+
+	s.weight.Add(sign * int64(s.weigher.Weigh(item.Key, item.Value)))
+}
+
+// evict removes items from the oldest bucket of the chain until the weight is within budget, the item that was just added or changed is kept.
+// The eviction handler runs once the bucket lock is released, while the item locks of the evicted items are still held.
+// The caller is expected to hold the item lock of keep, but not the bucket lock.
+func (s *GrowableMap[K, V]) evict(keep KeyValue[K, V]) {
+	if s.max_weight == 0 || s.Weight() <= s.max_weight {
+		return
+	}
+
+	var evicted []KeyValue[K, V]
+	locked := make([]*sync.Mutex, 0)
+	defer func() {
+		for _, item := range evicted {
+			s.on_evict(item)
+		}
+		for _, item_lock := range locked {
+			item_lock.Unlock()
+		}
+	}()
+
+	s.bucket_lock.Lock()
+	defer s.bucket_lock.Unlock()
+
+	for buckets := s.chain(); len(buckets) > 0 && s.Weight() > s.max_weight; buckets = s.chain() {
+		removed, empty := s.evictFrom(buckets[0], keep, &evicted, &locked)
+
+		if empty && len(buckets) > 1 {
+			rest := buckets[1:]
+			s.buckets.Store(&rest)
+			continue
+		}
+		if !removed {
+			return
+		}
+	}
+}
+
+// evictFrom removes items from the bucket until the weight is within budget, returns if anything was removed and if the bucket is now empty.
+// The removed items that need their eviction handler called are added to evicted, the item locks it took to locked, items whose lock is taken by others are skipped.
+// The caller is expected to hold the item lock of keep and the bucket lock.
+func (s *GrowableMap[K, V]) evictFrom(bucket *Fixed[K, V], keep KeyValue[K, V], evicted *[]KeyValue[K, V], locked *[]*sync.Mutex) (removed, empty bool) {
+	held := s.items_lock.GetLock(keep.Hash)
+
+	bucket.lock.Lock()
+	defer bucket.lock.Unlock()
+
+	empty = true
+	for i := range uint64(bucket.Len()) {
+		v, ok := bucket.load(i)
+		if !ok {
+			continue
+		}
+		if sameKey(v, keep) || s.Weight() <= s.max_weight {
+			empty = false
+			continue
+		}
+
+		// Waiting on the lock while holding the bucket lock could deadlock, so busy items are left for a later eviction
+		item_lock := s.items_lock.GetLock(v.Hash)
+		if item_lock != held && !slices.Contains(*locked, item_lock) {
+			if !item_lock.TryLock() {
+				empty = false
+				continue
+			}
+			*locked = append(*locked, item_lock)
+		}
+
+		bucket.clear(i)
+		s.addWeight(v, -1)
+		s.stats.Evict()
+		removed = true
+		if s.on_evict != nil && !v.Absent {
+			*evicted = append(*evicted, v)
+		}
+	}
+
+	return removed, empty
+}
+
+// Find returns the item with the same key, readers never take a lock and are not blocked by writers.
+func (s *GrowableMap[K, V]) Find(item KeyValue[K, V]) (KeyValue[K, V], bool) {
 	// Try to find it
-	for _, bucket := range s.buckets {
+	for _, bucket := range s.chain() {
+		if !bucket.HasHash(item.Hash) {
+			continue
+		}
+
 		v, ok := bucket.Get(item)
 		if ok {
 			return v, true
@@ -162,14 +396,47 @@ func (s *GrowableMap[K, V]) Find(item KeyValue[K, V]) (KeyValue[K, V], bool) {
 	return item, false
 }
 
-// Read returns an iterator that reads the items in the set.
+// ref returns the stored item with the same key so its value can be changed in place, or nil if it is not present, see [Fixed.ref]
+func (s *GrowableMap[K, V]) ref(item KeyValue[K, V]) *KeyValue[K, V] {
+	for _, bucket := range s.chain() {
+		if !bucket.HasHash(item.Hash) {
+			continue
+		}
+
+		if v := bucket.ref(item); v != nil {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// refs returns a sequence of the stored items, see [Fixed.ref]
+func (s *GrowableMap[K, V]) refs() iter.Seq[*KeyValue[K, V]] {
+	return func(yield func(*KeyValue[K, V]) bool) {
+		for _, bucket := range s.chain() {
+			for v := range bucket.refs() {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the map, all zero unless created with [WithStats]
+func (s *GrowableMap[K, V]) Stats() stats.Stats {
+	return s.stats.Stats()
+}
+
+// Read returns an iterator that reads the items in the set, skipping negative entries.
 func (s *GrowableMap[K, V]) Read() iter.Seq[KeyValue[K, V]] {
 	return func(yield func(KeyValue[K, V]) bool) {
-		s.bucket_lock.RLock()
-		defer s.bucket_lock.RUnlock()
-
-		for _, bucket := range s.buckets {
+		for _, bucket := range s.chain() {
 			for v := range bucket.Read() {
+				if v.Absent {
+					continue
+				}
 				if !yield(v) {
 					return
 				}
@@ -184,7 +451,7 @@ func (s *GrowableMap[K, V]) Range(yield func(item KeyValue[K, V]) bool) {
 }
 
 func (s *GrowableMap[K, V]) String() string {
-	return fmt.Sprintf("large.GrowableMap[%s,%s,%v]", generics.NameOf[K](), generics.NameOf[V](), len(s.buckets))
+	return fmt.Sprintf("large.GrowableMap[%s,%s,%v]", generics.NameOf[K](), generics.NameOf[V](), len(s.chain()))
 }
 
 func (s *GrowableMap[K, V]) GoString() string {
@@ -0,0 +1,321 @@
+package maps
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// ErrUniqueIndex is returned when a value would violate a unique index
+var ErrUniqueIndex = errors.New("unique index violation")
+
+// Index describes a secondary index on the values of a map, the extractor returns the index key of a value
+type Index[V any, IK comparable] struct {
+	name    string
+	extract func(value V) IK
+	unique  bool
+}
+
+// NewIndex creates a non-unique index, multiple keys can share the same index key
+func NewIndex[V any, IK comparable](name string, extract func(value V) IK) Index[V, IK] {
+	return Index[V, IK]{
+		name:    name,
+		extract: extract,
+		unique:  false,
+	}
+}
+
+// NewUniqueIndex creates a unique index, only a single key can hold a given index key
+func NewUniqueIndex[V any, IK comparable](name string, extract func(value V) IK) Index[V, IK] {
+	return Index[V, IK]{
+		name:    name,
+		extract: extract,
+		unique:  true,
+	}
+}
+
+// indexer is the type erased part of an index that the [Indexed] map maintains
+type indexer[K, V comparable] interface {
+	// insert adds the key under the index key of the value, returns true if it was added and not already present
+	insert(key K, value V) (bool, error)
+	// remove removes the key from the index key of the value
+	remove(key K, value V)
+	// changed returns true if the index keys of the values differ
+	changed(old, value V) bool
+}
+
+// Indexed is a [Bucketted] map that maintains secondary indexes over its values.
+// Indexes are updated under the same per hash locks that protect the items, so a key and its index entries change together.
+type Indexed[K, V comparable] struct {
+	data       *Bucketted[K, V]
+	indexes    []indexer[K, V]
+	index_lock sync.RWMutex
+}
+
+// NewIndexedMap creates a new Indexed map with the specified capacity, hasher, and options.
+// Eviction is not supported, as it would leave the index entries of evicted items behind.
+func NewIndexedMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*Indexed[K, V], error) {
+	data, err := NewBuckettedMap[K, V](capacity, keyhasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := data.base.withoutEviction("indexed map"); err != nil {
+		return nil, err
+	}
+
+	return &Indexed[K, V]{
+		data:       data,
+		indexes:    make([]indexer[K, V], 0),
+		index_lock: sync.RWMutex{},
+	}, nil
+}
+
+// Get retrieves the value for the specified key from the map.
+func (m *Indexed[K, V]) Get(key K) (KeyValue[K, V], bool) {
+	return m.data.Get(key)
+}
+
+// Set will add or update the value for the specified key in the map. It returns true if the value was added, false if it was updated.
+// If the value violates a unique index, nothing is changed and an error wrapping [ErrUniqueIndex] is returned.
+func (m *Indexed[K, V]) Set(key K, value V) (bool, error) {
+	m.index_lock.RLock()
+	defer m.index_lock.RUnlock()
+
+	kv, bucket := m.data.locate(key)
+	kv.Value = value
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	old, exists := bucket.Find(kv)
+
+	inserted := make([]indexer[K, V], 0, len(m.indexes))
+	for _, index := range m.indexes {
+		ok, err := index.insert(key, value)
+		if err != nil {
+			// Undo the entries that this call added, the ones already present belong to the old value
+			for _, index := range inserted {
+				index.remove(key, value)
+			}
+
+			return false, err
+		}
+		if ok {
+			inserted = append(inserted, index)
+		}
+	}
+
+	added := bucket.unsafeUpdateOrAdd(kv)
+	if exists && !old.Absent {
+		for _, index := range m.indexes {
+			if index.changed(old.Value, value) {
+				index.remove(key, old.Value)
+			}
+		}
+	}
+
+	return added, nil
+}
+
+// Delete removes the value for the specified key from the map and its indexes. It returns the removed item and true if it was found.
+func (m *Indexed[K, V]) Delete(key K) (KeyValue[K, V], bool) {
+	m.index_lock.RLock()
+	defer m.index_lock.RUnlock()
+
+	kv, bucket := m.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	old, ok := bucket.unsafeDelete(kv)
+	if !ok || old.Absent {
+		return EmptyKeyValue[K, V](), false
+	}
+
+	for _, index := range m.indexes {
+		index.remove(key, old.Value)
+	}
+
+	return old, true
+}
+
+// Read will return a sequence of all items in the map
+func (m *Indexed[K, V]) Read() iter.Seq[KeyValue[K, V]] {
+	return m.data.Read()
+}
+
+// Keys will return a sequence of all keys in the map
+func (m *Indexed[K, V]) Keys() iter.Seq[K] {
+	return m.data.Keys()
+}
+
+// Values will return a sequence of all values in the map
+func (m *Indexed[K, V]) Values() iter.Seq[V] {
+	return m.data.Values()
+}
+
+// KeyValues will return a sequence of all items in the map
+func (m *Indexed[K, V]) KeyValues() iter.Seq2[K, V] {
+	return m.data.KeyValues()
+}
+
+// Range will iterate over all items in the map
+func (m *Indexed[K, V]) Range(yield func(item KeyValue[K, V]) bool) {
+	m.data.Range(yield)
+}
+
+func (m *Indexed[K, V]) String() string {
+	return fmt.Sprintf("maps.Indexed[%s,%s,%d]", generics.NameOf[K](), generics.NameOf[V](), len(m.indexes))
+}
+
+func (m *Indexed[K, V]) GoString() string {
+	return m.String()
+}
+
+// IndexLookup is a registered index on an [Indexed] map, that can be used to find items by their index key
+type IndexLookup[K, V, IK comparable] struct {
+	Index[V, IK]
+	source  *Indexed[K, V]
+	entries map[IK]map[K]struct{}
+	lock    sync.RWMutex
+}
+
+// RegisterIndex adds the index to the map, existing items are indexed before it is returned.
+// If the existing items violate a unique index, the index is not registered and an error wrapping [ErrUniqueIndex] is returned.
+func RegisterIndex[K, V, IK comparable](m *Indexed[K, V], index Index[V, IK]) (*IndexLookup[K, V, IK], error) {
+	if index.extract == nil {
+		return nil, errors.New("index extractor is nil")
+	}
+
+	m.index_lock.Lock()
+	defer m.index_lock.Unlock()
+
+	lookup := &IndexLookup[K, V, IK]{
+		Index:   index,
+		source:  m,
+		entries: make(map[IK]map[K]struct{}),
+		lock:    sync.RWMutex{},
+	}
+
+	for item := range m.data.Read() {
+		if _, err := lookup.insert(item.Key, item.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	m.indexes = append(m.indexes, lookup)
+	return lookup, nil
+}
+
+// Name returns the name of the index
+func (l *IndexLookup[K, V, IK]) Name() string {
+	return l.name
+}
+
+// Unique returns true if the index only allows a single key per index key
+func (l *IndexLookup[K, V, IK]) Unique() bool {
+	return l.unique
+}
+
+// Get returns a sequence of all items whose value has the given index key
+func (l *IndexLookup[K, V, IK]) Get(ik IK) iter.Seq[KeyValue[K, V]] {
+	return func(yield func(KeyValue[K, V]) bool) {
+		for _, key := range l.keys(ik) {
+			item, ok := l.source.data.Get(key)
+			// The item might have changed between reading the index and the map
+			if !ok || l.extract(item.Value) != ik {
+				continue
+			}
+
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// First returns the first item whose value has the given index key, useful for unique indexes
+func (l *IndexLookup[K, V, IK]) First(ik IK) (KeyValue[K, V], bool) {
+	for item := range l.Get(ik) {
+		return item, true
+	}
+
+	return EmptyKeyValue[K, V](), false
+}
+
+// Count returns the amount of keys stored under the index key
+func (l *IndexLookup[K, V, IK]) Count(ik IK) int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return len(l.entries[ik])
+}
+
+func (l *IndexLookup[K, V, IK]) keys(ik IK) []K {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	set := l.entries[ik]
+	keys := make([]K, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (l *IndexLookup[K, V, IK]) insert(key K, value V) (bool, error) {
+	ik := l.extract(value)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	set, ok := l.entries[ik]
+	if !ok {
+		set = make(map[K]struct{}, 1)
+		l.entries[ik] = set
+	}
+	if _, ok := set[key]; ok {
+		return false, nil
+	}
+	if l.unique && len(set) > 0 {
+		return false, fmt.Errorf("%w: %s already holds %v", ErrUniqueIndex, l.name, ik)
+	}
+
+	set[key] = struct{}{}
+	return true, nil
+}
+
+func (l *IndexLookup[K, V, IK]) remove(key K, value V) {
+	ik := l.extract(value)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	set, ok := l.entries[ik]
+	if !ok {
+		return
+	}
+
+	delete(set, key)
+	if len(set) == 0 {
+		delete(l.entries, ik)
+	}
+}
+
+func (l *IndexLookup[K, V, IK]) changed(old, value V) bool {
+	return l.extract(old) != l.extract(value)
+}
+
+func (l *IndexLookup[K, V, IK]) String() string {
+	return fmt.Sprintf("maps.IndexLookup[%s,%s]", l.name, generics.NameOf[IK]())
+}
+
+func (l *IndexLookup[K, V, IK]) GoString() string {
+	return l.String()
+}
@@ -0,0 +1,133 @@
+package maps_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Email  string
+	Tenant int
+}
+
+func Test_Indexed(t *testing.T) {
+	col, err := maps.NewIndexedMap[int, user](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	byEmail, err := maps.RegisterIndex(col, maps.NewUniqueIndex("email", func(v user) string { return v.Email }))
+	require.NoError(t, err)
+	byTenant, err := maps.RegisterIndex(col, maps.NewIndex("tenant", func(v user) int { return v.Tenant }))
+	require.NoError(t, err)
+
+	for i := range 10 {
+		added, err := col.Set(i, user{Email: fmt.Sprintf("%d@example.com", i), Tenant: i % 2})
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+
+	// Unique lookups
+	v, ok := byEmail.First("3@example.com")
+	require.True(t, ok)
+	require.Equal(t, 3, v.Key)
+
+	// Non unique lookups
+	count := 0
+	for item := range byTenant.Get(1) {
+		require.Equal(t, 1, item.Value.Tenant)
+		count++
+	}
+	require.Equal(t, 5, count)
+	require.Equal(t, 5, byTenant.Count(0))
+
+	// Unique violations leave everything untouched
+	_, err = col.Set(4, user{Email: "3@example.com", Tenant: 1})
+	require.ErrorIs(t, err, maps.ErrUniqueIndex)
+	v, ok = col.Get(4)
+	require.True(t, ok)
+	require.Equal(t, "4@example.com", v.Value.Email)
+	require.Equal(t, 5, byTenant.Count(1))
+
+	// Updates move the index entries
+	added, err := col.Set(4, user{Email: "four@example.com", Tenant: 1})
+	require.NoError(t, err)
+	require.False(t, added)
+	_, ok = byEmail.First("4@example.com")
+	require.False(t, ok)
+	v, ok = byEmail.First("four@example.com")
+	require.True(t, ok)
+	require.Equal(t, 4, v.Key)
+	require.Equal(t, 4, byTenant.Count(0))
+	require.Equal(t, 6, byTenant.Count(1))
+
+	// Deletes remove the index entries
+	_, ok = col.Delete(4)
+	require.True(t, ok)
+	_, ok = byEmail.First("four@example.com")
+	require.False(t, ok)
+	require.Equal(t, 5, byTenant.Count(1))
+
+	// Updating to the same index key keeps the entry
+	_, err = col.Set(3, user{Email: "3@example.com", Tenant: 0})
+	require.NoError(t, err)
+	require.Equal(t, 1, byEmail.Count("3@example.com"))
+}
+
+func Test_Indexed_Register_Existing(t *testing.T) {
+	col, err := maps.NewIndexedMap[int, user](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	_, err = col.Set(1, user{Email: "same@example.com"})
+	require.NoError(t, err)
+	_, err = col.Set(2, user{Email: "same@example.com"})
+	require.NoError(t, err)
+
+	_, err = maps.RegisterIndex(col, maps.NewUniqueIndex("email", func(v user) string { return v.Email }))
+	require.ErrorIs(t, err, maps.ErrUniqueIndex)
+
+	byEmail, err := maps.RegisterIndex(col, maps.NewIndex("email", func(v user) string { return v.Email }))
+	require.NoError(t, err)
+	require.Equal(t, 2, byEmail.Count("same@example.com"))
+}
+
+func Test_Indexed_RejectsEviction(t *testing.T) {
+	_, err := maps.NewIndexedMap[int, user](100, test_util.CheapIntHasher[int](),
+		maps.WithWeigher(maps.WeigherFunc[int, user](func(key int, value user) uint64 { return 1 })),
+		maps.WithMaxWeight(10),
+	)
+	require.Error(t, err)
+
+	_, err = maps.NewIndexedMap[int, user](100, test_util.CheapIntHasher[int](), maps.WithEvictionHandler(func(item maps.KeyValue[int, user]) {}))
+	require.Error(t, err)
+}
+
+func Test_Indexed_Concurrency(t *testing.T) {
+	col, err := maps.NewIndexedMap[int, user](1000, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	byEmail, err := maps.RegisterIndex(col, maps.NewUniqueIndex("email", func(v user) string { return v.Email }))
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Every worker fights over the same emails with different keys
+			for i := range 100 {
+				_, _ = col.Set(w*100+i, user{Email: fmt.Sprintf("%d@example.com", i)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 100 {
+		require.Equal(t, 1, byEmail.Count(fmt.Sprintf("%d@example.com", i)))
+	}
+}
@@ -6,17 +6,18 @@ import (
 )
 
 type KeyValue[K comparable, V any] struct {
-	Hash  uint64 // The hash of the key marked for empty checks. See [hashmark.MarkedHash]
-	Key   K
-	Value V
+	Hash   uint64 // The hash of the key marked for empty checks. See [hashmark.MarkedHash]
+	Key    K
+	Value  V
+	Absent bool // Set for negative entries, recording that the key is known to be absent, see [Bucketted.SetAbsent]
 }
 
 // NewKeyValue creates a new KeyValue instance with the given key and value.
 func NewKeyValue[K comparable, V any](hash uint64, key K, value V) KeyValue[K, V] {
 	return KeyValue[K, V]{
-		hashmark.MarkedHash(hash),
-		key,
-		value,
+		Hash:  hashmark.MarkedHash(hash),
+		Key:   key,
+		Value: value,
 	}
 }
 
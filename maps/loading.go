@@ -0,0 +1,400 @@
+package maps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// ErrNotFound is returned by a [Loader] when the key does not exist at its source, wrapped or as is.
+// With [WithNegativeTTL] the [Loading] map remembers it, and returns it for the key without calling the loader again.
+var ErrNotFound = errors.New("not found")
+
+// Loader loads the value for a key from its source, used by a [Loading] map on misses and refreshes
+type Loader[K, V any] func(ctx context.Context, key K) (V, error)
+
+// LoadingOptions are the options for a [Loading] map.
+type LoadingOptions struct {
+	ttl            time.Duration
+	negative_ttl   time.Duration
+	refresh_window time.Duration
+	grace          time.Duration
+	timeout        time.Duration
+	backoff        time.Duration
+	clock          func() time.Time
+	on_error       func(err error)
+	map_options    []options.Option[Options]
+	weigher        any // A [Weigher] matching the key and value types of the map
+	max_weight     uint64
+}
+
+// CreateLoadingOptions creates the options for a [Loading] map, entries live for a minute and are not refreshed ahead by default.
+func CreateLoadingOptions(opts ...options.Option[LoadingOptions]) (LoadingOptions, error) {
+	op := LoadingOptions{
+		ttl:            time.Minute,
+		negative_ttl:   0,
+		refresh_window: 0,
+		grace:          0,
+		timeout:        30 * time.Second,
+		backoff:        time.Second,
+		clock:          time.Now,
+		on_error:       nil,
+		map_options:    nil,
+		weigher:        nil,
+		max_weight:     0,
+	}
+
+	err := options.Apply(&op, opts...)
+	if err == nil && op.refresh_window > op.ttl {
+		err = errors.New("refresh window is larger than the ttl")
+	}
+
+	return op, err
+}
+
+// WithTTL sets how long a loaded value is considered fresh
+func WithTTL(ttl time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunctionE(func(option *LoadingOptions) error {
+		if ttl <= 0 {
+			return errors.New("ttl has to be larger than 0")
+		}
+
+		option.ttl = ttl
+		return nil
+	})
+}
+
+// WithNegativeTTL sets how long the map remembers that the loader reported a key as [ErrNotFound], 0 does not remember it
+func WithNegativeTTL(ttl time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunctionE(func(option *LoadingOptions) error {
+		if ttl < 0 {
+			return errors.New("negative ttl has to be 0 or larger")
+		}
+
+		option.negative_ttl = ttl
+		return nil
+	})
+}
+
+// WithRefreshAhead refreshes entries in the background once they are within the window of their expiry, while still serving the current value
+func WithRefreshAhead(window time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.refresh_window = max(window, 0)
+	})
+}
+
+// WithStaleGrace keeps serving expired values for the grace period while they are refreshed in the background, also when refreshing fails
+func WithStaleGrace(grace time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.grace = max(grace, 0)
+	})
+}
+
+// WithRefreshTimeout sets the timeout of the context that background refreshes receive
+func WithRefreshTimeout(timeout time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.timeout = timeout
+	})
+}
+
+// WithRefreshBackoff sets how long to wait before refreshing an entry again after a refresh failed
+func WithRefreshBackoff(backoff time.Duration) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.backoff = max(backoff, 0)
+	})
+}
+
+// WithClock sets the function used to get the current time
+func WithClock(clock func() time.Time) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.clock = clock
+	})
+}
+
+// WithRefreshErrorHandler sets the function that receives errors from background refreshes
+func WithRefreshErrorHandler(handler func(err error)) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.on_error = handler
+	})
+}
+
+// WithMapOptions sets the options of the underlying [Bucketted] map
+func WithMapOptions(opts ...options.Option[Options]) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.map_options = append(option.map_options, opts...)
+	})
+}
+
+// WithLoadedWeigher bounds the total weight of the loaded values, once exceeded entries are evicted from the oldest bucket of the chain in slot order,
+// which is not the order they were added in.
+// The weigher receives the values as loaded, unlike a weigher given through [WithMapOptions]
+func WithLoadedWeigher[K, V any](weigher Weigher[K, V], max_weight uint64) options.Option[LoadingOptions] {
+	return options.NewFunction(func(option *LoadingOptions) {
+		option.weigher = weigher
+		option.max_weight = max_weight
+	})
+}
+
+// loaded is a value with the moment it expires, if a refresh for it is running and when a refresh may be retried
+type loaded[V comparable] struct {
+	value      V
+	expires    time.Time
+	refreshing bool
+	retry_at   time.Time
+}
+
+// Loading is a [Bucketted] map that loads missing or expired values through a [Loader].
+// Values nearing expiry are refreshed in the background while the current value keeps being served,
+// only a single load or refresh runs per key at a time, guarded by the per hash item locks.
+type Loading[K, V comparable] struct {
+	LoadingOptions
+	data      *Bucketted[K, loaded[V]]
+	loader    Loader[K, V]
+	refreshes sync.WaitGroup
+}
+
+// NewLoadingMap creates a new Loading map with the specified capacity, hasher, loader and options.
+func NewLoadingMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K], loader Loader[K, V], opts ...options.Option[LoadingOptions]) (*Loading[K, V], error) {
+	if loader == nil {
+		return nil, errors.New("loader is nil")
+	}
+
+	base, err := CreateLoadingOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	map_options := base.map_options
+	if base.weigher != nil {
+		weigher, ok := base.weigher.(Weigher[K, V])
+		if !ok {
+			return nil, fmt.Errorf("weigher %T does not match the map types %s,%s", base.weigher, generics.NameOf[K](), generics.NameOf[V]())
+		}
+
+		map_options = append(map_options,
+			WithWeigher(WeigherFunc[K, loaded[V]](func(key K, value loaded[V]) uint64 { return weigher.Weigh(key, value.value) })),
+			WithMaxWeight(base.max_weight),
+		)
+	}
+
+	data, err := NewBuckettedMap[K, loaded[V]](capacity, keyhasher, map_options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Loading[K, V]{
+		LoadingOptions: base,
+		data:           data,
+		loader:         loader,
+	}, nil
+}
+
+// Get returns the value for the key, loading it if it is missing or expired beyond the grace period.
+// Values within the refresh window or grace period are returned as is, and refreshed in the background.
+// Keys the loader recently reported as not found return an error wrapping [ErrNotFound], see [WithNegativeTTL].
+func (m *Loading[K, V]) Get(ctx context.Context, key K) (V, error) {
+	now := m.clock()
+	kv, bucket := m.data.locate(key)
+	item, ok := bucket.Find(kv)
+	if ok {
+		switch {
+		case item.Absent && now.Before(item.Value.expires):
+			bucket.stats.NegativeHit()
+			return item.Value.value, m.notFound(key)
+		case item.Absent:
+			// Expired negative entries are loaded again
+		case now.Before(item.Value.expires.Add(-m.refresh_window)):
+			bucket.stats.Hit()
+			return item.Value.value, nil
+		case now.Before(item.Value.expires.Add(m.grace)):
+			bucket.stats.Hit()
+			m.refreshAhead(item, now)
+			return item.Value.value, nil
+		}
+
+		bucket.stats.Expire()
+	}
+
+	bucket.stats.Miss()
+	return m.load(ctx, key)
+}
+
+// Set stores the value for the key as freshly loaded. It returns true if the value was added, false if it was updated.
+func (m *Loading[K, V]) Set(key K, value V) bool {
+	return m.data.Set(key, loaded[V]{
+		value:   value,
+		expires: m.clock().Add(m.ttl),
+	})
+}
+
+// Delete removes the value for the key. It returns the removed value and true if it was found.
+func (m *Loading[K, V]) Delete(key K) (V, bool) {
+	item, ok := m.data.Delete(key)
+	return item.Value.value, ok
+}
+
+// Refresh loads the value for the key, replacing whatever is stored, the current value is kept if it fails.
+func (m *Loading[K, V]) Refresh(ctx context.Context, key K) (V, error) {
+	kv, bucket := m.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	return m.unsafeLoad(ctx, bucket, kv)
+}
+
+// Wait blocks until all background refreshes that are running have finished.
+func (m *Loading[K, V]) Wait() {
+	m.refreshes.Wait()
+}
+
+// Stats returns the statistics of the map, all zero unless created with [WithStats] in [WithMapOptions]
+func (m *Loading[K, V]) Stats() stats.Stats {
+	return m.data.Stats()
+}
+
+// Read will return a sequence of all items in the map, including expired ones that have not been reloaded yet
+func (m *Loading[K, V]) Read() iter.Seq[KeyValue[K, V]] {
+	return func(yield func(KeyValue[K, V]) bool) {
+		for item := range m.data.Read() {
+			if !yield(NewKeyValue(item.Hash, item.Key, item.Value.value)) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Loading[K, V]) String() string {
+	return fmt.Sprintf("maps.Loading[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *Loading[K, V]) GoString() string {
+	return m.String()
+}
+
+// load loads the key unless another caller did so while we waited on the item lock
+func (m *Loading[K, V]) load(ctx context.Context, key K) (V, error) {
+	kv, bucket := m.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	if item, ok := bucket.Find(kv); ok && m.clock().Before(item.Value.expires) {
+		if item.Absent {
+			return item.Value.value, m.notFound(key)
+		}
+		return item.Value.value, nil
+	}
+
+	return m.unsafeLoad(ctx, bucket, kv)
+}
+
+// unsafeLoad calls the loader and stores the result, the caller is expected to hold the item lock
+func (m *Loading[K, V]) unsafeLoad(ctx context.Context, bucket *GrowableMap[K, loaded[V]], kv KeyValue[K, loaded[V]]) (V, error) {
+	start := time.Now()
+	value, err := m.loader(ctx, kv.Key)
+	bucket.stats.Load(time.Since(start), err)
+	if err != nil {
+		if m.remembersAbsent(err) {
+			m.unsafeSetAbsent(bucket, kv)
+		}
+		return value, err
+	}
+
+	kv.Value = loaded[V]{
+		value:   value,
+		expires: m.clock().Add(m.ttl),
+	}
+	bucket.unsafeUpdateOrAdd(kv)
+	return value, nil
+}
+
+// refreshAhead marks the item as refreshing and starts a background refresh, unless one is already running
+func (m *Loading[K, V]) refreshAhead(item KeyValue[K, loaded[V]], now time.Time) {
+	if item.Value.refreshing || now.Before(item.Value.retry_at) {
+		return
+	}
+
+	kv, bucket := m.data.locate(item.Key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	current, ok := bucket.Find(kv)
+	if !ok || current.Value.refreshing || current.Value.expires != item.Value.expires {
+		return
+	}
+
+	current.Value.refreshing = true
+	bucket.unsafeUpdateOrAdd(current)
+
+	m.refreshes.Add(1)
+	go m.refresh(current)
+}
+
+func (m *Loading[K, V]) refresh(item KeyValue[K, loaded[V]]) {
+	defer m.refreshes.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	// The loader runs without the item lock so gets keep being served the current value
+	start := time.Now()
+	value, err := m.loader(ctx, item.Key)
+
+	_, bucket := m.data.locate(item.Key)
+	bucket.stats.Load(time.Since(start), err)
+	item_lock := bucket.lockItem(item.Hash)
+	defer item_lock.Unlock()
+
+	current, ok := bucket.Find(item)
+	// Deleted or replaced while refreshing, the newer state wins
+	if !ok || current.Value != item.Value {
+		return
+	}
+
+	if m.remembersAbsent(err) {
+		m.unsafeSetAbsent(bucket, current)
+		return
+	}
+	if err != nil {
+		current.Value.refreshing = false
+		current.Value.retry_at = m.clock().Add(m.backoff)
+		bucket.unsafeUpdateOrAdd(current)
+
+		if m.on_error != nil {
+			m.on_error(fmt.Errorf("refreshing %v: %w", item.Key, err))
+		}
+		return
+	}
+
+	current.Value = loaded[V]{
+		value:   value,
+		expires: m.clock().Add(m.ttl),
+	}
+	bucket.unsafeUpdateOrAdd(current)
+}
+
+// remembersAbsent returns true if the error reports the key as not found, and the map remembers such keys
+func (m *Loading[K, V]) remembersAbsent(err error) bool {
+	return m.negative_ttl > 0 && errors.Is(err, ErrNotFound)
+}
+
+// unsafeSetAbsent replaces the item with a negative entry that expires after the negative ttl, the caller is expected to hold the item lock
+func (m *Loading[K, V]) unsafeSetAbsent(bucket *GrowableMap[K, loaded[V]], kv KeyValue[K, loaded[V]]) {
+	kv.Value = loaded[V]{expires: m.clock().Add(m.negative_ttl)}
+	kv.Absent = true
+	bucket.unsafeUpdateOrAdd(kv)
+}
+
+func (m *Loading[K, V]) notFound(key K) error {
+	return fmt.Errorf("%w: %v", ErrNotFound, key)
+}
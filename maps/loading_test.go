@@ -0,0 +1,210 @@
+package maps_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// countingLoader returns "<key>-<load number>" and fails while fail is set
+type countingLoader struct {
+	loads atomic.Int32
+	fail  atomic.Bool
+}
+
+func (l *countingLoader) Load(ctx context.Context, key int) (string, error) {
+	n := l.loads.Add(1)
+	if l.fail.Load() {
+		return "", errors.New("source is unavailable")
+	}
+
+	return fmt.Sprintf("%d-%d", key, n), nil
+}
+
+func Test_Loading_RefreshAhead(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	loader := &countingLoader{}
+
+	col, err := maps.NewLoadingMap[int, string](100, test_util.CheapIntHasher[int](), loader.Load,
+		maps.WithTTL(time.Minute),
+		maps.WithRefreshAhead(10*time.Second),
+		maps.WithClock(clock.Now),
+	)
+	require.NoError(t, err)
+
+	v, err := col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1-1", v)
+
+	// Fresh values are served from the map
+	clock.Advance(30 * time.Second)
+	v, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1-1", v)
+	require.EqualValues(t, 1, loader.loads.Load())
+
+	// Within the window the current value is served while it refreshes
+	clock.Advance(25 * time.Second)
+	v, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1-1", v)
+	col.Wait()
+
+	v, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1-2", v)
+	require.EqualValues(t, 2, loader.loads.Load())
+}
+
+func Test_Loading_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	loader := &countingLoader{}
+	failures := atomic.Int32{}
+
+	col, err := maps.NewLoadingMap[int, string](100, test_util.CheapIntHasher[int](), loader.Load,
+		maps.WithTTL(time.Minute),
+		maps.WithStaleGrace(time.Minute),
+		maps.WithRefreshBackoff(10*time.Second),
+		maps.WithClock(clock.Now),
+		maps.WithRefreshErrorHandler(func(err error) { failures.Add(1) }),
+	)
+	require.NoError(t, err)
+
+	v, err := col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1-1", v)
+
+	// Refreshing fails, the stale value keeps being served
+	loader.fail.Store(true)
+	clock.Advance(70 * time.Second)
+	for range 10 {
+		v, err = col.Get(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "1-1", v)
+		col.Wait()
+	}
+	require.EqualValues(t, 1, failures.Load(), "refreshes back off after a failure")
+
+	// Beyond the grace period the load is done in the foreground
+	clock.Advance(time.Minute)
+	_, err = col.Get(ctx, 1)
+	require.Error(t, err)
+
+	loader.fail.Store(false)
+	v, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("1-%d", loader.loads.Load()), v)
+}
+
+func Test_Loading_SingleRefresh(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	release := make(chan struct{})
+	loads := atomic.Int32{}
+
+	loader := func(ctx context.Context, key int) (string, error) {
+		if loads.Add(1) > 1 {
+			<-release
+		}
+
+		return "value", nil
+	}
+
+	col, err := maps.NewLoadingMap[int, string](100, test_util.CheapIntHasher[int](), loader,
+		maps.WithTTL(time.Minute),
+		maps.WithRefreshAhead(30*time.Second),
+		maps.WithClock(clock.Now),
+	)
+	require.NoError(t, err)
+
+	_, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+	clock.Advance(45 * time.Second)
+
+	wg := sync.WaitGroup{}
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, err := col.Get(ctx, 1)
+			require.NoError(t, err)
+			require.Equal(t, "value", v)
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	col.Wait()
+	require.EqualValues(t, 2, loads.Load())
+}
+
+func Test_Loading_NegativeTTL(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	loads := atomic.Int32{}
+	exists := atomic.Bool{}
+	loader := func(ctx context.Context, key int) (string, error) {
+		loads.Add(1)
+		if !exists.Load() {
+			return "", fmt.Errorf("key %d: %w", key, maps.ErrNotFound)
+		}
+
+		return fmt.Sprint(key), nil
+	}
+
+	col, err := maps.NewLoadingMap[int, string](100, test_util.CheapIntHasher[int](), loader,
+		maps.WithNegativeTTL(10*time.Second),
+		maps.WithClock(clock.Now),
+	)
+	require.NoError(t, err)
+
+	_, err = col.Get(ctx, 1)
+	require.ErrorIs(t, err, maps.ErrNotFound)
+	require.Equal(t, int32(1), loads.Load())
+
+	// Remembered, the loader is not called again
+	exists.Store(true)
+	_, err = col.Get(ctx, 1)
+	require.ErrorIs(t, err, maps.ErrNotFound)
+	require.Equal(t, int32(1), loads.Load())
+	require.Empty(t, slices.Collect(col.Read()))
+
+	// Forgotten after the negative ttl
+	clock.Advance(11 * time.Second)
+	v, err := col.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1", v)
+	require.Equal(t, int32(2), loads.Load())
+}
@@ -0,0 +1,171 @@
+package maps
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// multiValues are the values of a key in a [MultiMap], replaced as a whole on every change so readers never lock
+type multiValues[V comparable] struct {
+	values []V
+}
+
+// MultiMap is a map that holds multiple values per key, in the order they were added.
+// Changes to the values of a key are serialized by the per hash item locks, readers see the values as they were at some point and never lock.
+// With [WithUniqueValues] the values of a key form a set.
+type MultiMap[K, V comparable] struct {
+	data   *Bucketted[K, *multiValues[V]]
+	unique bool
+}
+
+// NewMultiMap creates a new MultiMap with the specified capacity, hasher, and options.
+// Options that depend on the value type, such as [WithWeigher], are not supported, neither is eviction, as it would drop every value of a key.
+func NewMultiMap[K, V comparable](capacity uint64, keyhasher hash.Hasher[K], opts ...options.Option[Options]) (*MultiMap[K, V], error) {
+	data, err := NewBuckettedMap[K, *multiValues[V]](capacity, keyhasher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := data.base.withoutEviction("multi map"); err != nil {
+		return nil, err
+	}
+
+	return &MultiMap[K, V]{
+		data:   data,
+		unique: data.base.unique_values,
+	}, nil
+}
+
+// find returns the current values of the key, without locking
+func (m *MultiMap[K, V]) find(key K) []V {
+	kv, bucket := m.data.locate(key)
+	current, ok := bucket.Find(kv)
+	if !ok {
+		bucket.stats.Miss()
+		return nil
+	}
+
+	bucket.stats.Hit()
+	return current.Value.values
+}
+
+// GetAll returns a sequence of the values of the key
+func (m *MultiMap[K, V]) GetAll(key K) iter.Seq[V] {
+	values := m.find(key)
+
+	return func(yield func(V) bool) {
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Count returns the amount of values of the key
+func (m *MultiMap[K, V]) Count(key K) int {
+	return len(m.find(key))
+}
+
+// Has returns true if the key has the value
+func (m *MultiMap[K, V]) Has(key K, value V) bool {
+	return slices.Contains(m.find(key), value)
+}
+
+// Add adds the value to the key. It returns false if the values are unique and the key already has the value.
+func (m *MultiMap[K, V]) Add(key K, value V) bool {
+	kv, bucket := m.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	var values []V
+	if current, ok := bucket.Find(kv); ok {
+		values = current.Value.values
+	}
+	if m.unique && slices.Contains(values, value) {
+		return false
+	}
+
+	next := make([]V, len(values), len(values)+1)
+	copy(next, values)
+	kv.Value = &multiValues[V]{append(next, value)}
+	bucket.unsafeUpdateOrAdd(kv)
+
+	return true
+}
+
+// Remove removes the first occurrence of the value from the key, the key is removed with its last value. It returns true if the value was found.
+func (m *MultiMap[K, V]) Remove(key K, value V) bool {
+	kv, bucket := m.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	current, ok := bucket.Find(kv)
+	if !ok {
+		return false
+	}
+
+	values := current.Value.values
+	i := slices.Index(values, value)
+	if i < 0 {
+		return false
+	}
+
+	if len(values) == 1 {
+		bucket.unsafeDelete(kv)
+		return true
+	}
+
+	kv.Value = &multiValues[V]{slices.Delete(slices.Clone(values), i, i+1)}
+	bucket.unsafeUpdateOrAdd(kv)
+
+	return true
+}
+
+// RemoveAll removes the key with all its values. It returns the amount of values removed.
+func (m *MultiMap[K, V]) RemoveAll(key K) int {
+	v, ok := m.data.Delete(key)
+	if !ok {
+		return 0
+	}
+
+	return len(v.Value.values)
+}
+
+// Keys will return a sequence of all keys with at least one value
+func (m *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.data.Keys()
+}
+
+// KeyValues will return a sequence of all values with their key, a key is repeated for each of its values
+func (m *MultiMap[K, V]) KeyValues() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for item := range m.data.Read() {
+			for _, v := range item.Value.values {
+				if !yield(item.Key, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the map summed over its buckets, all zero unless created with [WithStats]
+func (m *MultiMap[K, V]) Stats() stats.Stats {
+	return m.data.Stats()
+}
+
+func (m *MultiMap[K, V]) String() string {
+	return fmt.Sprintf("maps.MultiMap[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *MultiMap[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,74 @@
+package maps_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MultiMap(t *testing.T) {
+	m, err := maps.NewMultiMap[int, string](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	require.True(t, m.Add(1, "a"))
+	require.True(t, m.Add(1, "b"))
+	require.True(t, m.Add(1, "a"))
+	require.True(t, m.Add(2, "c"))
+
+	require.Equal(t, []string{"a", "b", "a"}, slices.Collect(m.GetAll(1)))
+	require.Equal(t, 3, m.Count(1))
+	require.Equal(t, 0, m.Count(3))
+	require.True(t, m.Has(2, "c"))
+
+	require.True(t, m.Remove(1, "a"))
+	require.Equal(t, []string{"b", "a"}, slices.Collect(m.GetAll(1)))
+	require.False(t, m.Remove(1, "c"))
+
+	require.True(t, m.Remove(2, "c"))
+	require.False(t, m.Remove(2, "c"))
+	require.Equal(t, []int{1}, slices.Collect(m.Keys()))
+
+	require.Equal(t, 2, m.RemoveAll(1))
+	require.Equal(t, 0, m.RemoveAll(1))
+	require.Empty(t, slices.Collect(m.Keys()))
+}
+
+func Test_MultiMap_UniqueValues(t *testing.T) {
+	m, err := maps.NewMultiMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithUniqueValues())
+	require.NoError(t, err)
+
+	require.True(t, m.Add(1, "a"))
+	require.False(t, m.Add(1, "a"))
+	require.True(t, m.Add(1, "b"))
+	require.Equal(t, 2, m.Count(1))
+
+	_, err = maps.NewMultiMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithMaxWeight(10))
+	require.Error(t, err)
+}
+
+func Test_MultiMap_Concurrent(t *testing.T) {
+	m, err := maps.NewMultiMap[int, int](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				m.Add(i%10, w*1000+i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for k := range 10 {
+		total += m.Count(k)
+	}
+	require.Equal(t, 8000, total)
+}
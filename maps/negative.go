@@ -0,0 +1,64 @@
+package maps
+
+// Presence tells what a map knows about a key, see [Bucketted.Lookup]
+type Presence uint8
+
+const (
+	// Unknown means the map holds nothing for the key
+	Unknown Presence = iota
+	// Found means the map holds a value for the key
+	Found
+	// Absent means the key is known to be absent, through a negative entry. See [Bucketted.SetAbsent]
+	Absent
+)
+
+func (p Presence) String() string {
+	switch p {
+	case Found:
+		return "found"
+	case Absent:
+		return "absent"
+	default:
+		return "unknown"
+	}
+}
+
+// Lookup retrieves the value for the specified key from the Bucketted, telling apart keys that are known to be absent from keys it knows nothing about.
+func (m *Bucketted[K, V]) Lookup(key K) (KeyValue[K, V], Presence) {
+	kv, bucket := m.locate(key)
+	v, ok := bucket.Find(kv)
+	switch {
+	case !ok:
+		bucket.stats.Miss()
+		return EmptyKeyValue[K, V](), Unknown
+	case v.Absent:
+		bucket.stats.NegativeHit()
+		return EmptyKeyValue[K, V](), Absent
+	default:
+		bucket.stats.Hit()
+		return v, Found
+	}
+}
+
+// SetAbsent records that the key is known to be absent, through a negative entry that holds no value.
+// Negative entries are skipped when reading the map, replaced by setting a value for the key, and dropped when the map grows.
+// They do not expire, callers are expected to clear them with [Bucketted.Delete] once the key might exist, see [WithNegativeTTL] for a map that does.
+// It returns the value that was removed for it and true if there was one.
+func (m *Bucketted[K, V]) SetAbsent(key K) (KeyValue[K, V], bool) {
+	kv, bucket := m.locate(key)
+	kv.Absent = true
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	old, ok := bucket.updateIf(kv)
+	if !ok {
+		bucket.set(kv)
+		return EmptyKeyValue[K, V](), false
+	}
+	if old.Absent {
+		return EmptyKeyValue[K, V](), false
+	}
+
+	return old, true
+}
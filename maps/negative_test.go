@@ -0,0 +1,79 @@
+package maps_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Bucketted_NegativeEntries(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+
+	_, presence := col.Lookup(1)
+	require.Equal(t, maps.Unknown, presence)
+
+	_, removed := col.SetAbsent(1)
+	require.False(t, removed)
+	_, presence = col.Lookup(1)
+	require.Equal(t, maps.Absent, presence)
+	_, ok := col.Get(1)
+	require.False(t, ok)
+
+	// Negative entries are not part of the content
+	col.Set(2, "two")
+	require.Equal(t, []int{2}, slices.Collect(col.Keys()))
+
+	// Setting a value replaces the negative entry
+	require.True(t, col.Set(1, "one"))
+	v, presence := col.Lookup(1)
+	require.Equal(t, maps.Found, presence)
+	require.Equal(t, "one", v.Value)
+
+	v, removed = col.SetAbsent(1)
+	require.True(t, removed)
+	require.Equal(t, "one", v.Value)
+
+	// Deleting forgets the negative entry, without reporting it as removed
+	_, ok = col.Delete(1)
+	require.False(t, ok)
+	_, presence = col.Lookup(1)
+	require.Equal(t, maps.Unknown, presence)
+}
+
+func Test_Bucketted_NegativeEntries_Stats(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithStats())
+	require.NoError(t, err)
+
+	col.SetAbsent(1)
+	col.Set(2, "two")
+	col.Lookup(1)
+	col.Get(1)
+	for range col.GetMany([]int{1, 2, 3}) {
+	}
+
+	// Known absent keys are counted apart, so they do not raise the hit ratio
+	s := col.Stats()
+	require.Equal(t, uint64(3), s.NegativeHits)
+	require.Equal(t, uint64(1), s.Hits)
+	require.Equal(t, uint64(1), s.Misses)
+	require.InDelta(t, 1.0/5.0, s.HitRatio(), 0.001)
+}
+
+func Test_Bucketted_NegativeEntries_PointerFree(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithPointerFree())
+	require.NoError(t, err)
+
+	col.Set(1, "one")
+	col.SetAbsent(1)
+	_, presence := col.Lookup(1)
+	require.Equal(t, maps.Absent, presence)
+
+	col.Set(1, "uno")
+	v, presence := col.Lookup(1)
+	require.Equal(t, maps.Found, presence)
+	require.Equal(t, "uno", v.Value)
+}
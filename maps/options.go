@@ -1,7 +1,10 @@
 package maps
 
 import (
+	"fmt"
+
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-locks"
 	optimal "github.com/daanv2/go-optimal"
 	"github.com/daanv2/go-optimal/pkg/cpu"
@@ -13,6 +16,14 @@ type Options struct {
 	items_lock       *locks.Pool
 	bucket_amount    uint64
 	bucket_amount_fn func(uint64) uint64
+	weigher          any // A [Weigher] matching the key and value types of the map
+	max_weight       uint64
+	on_evict         any // A func(KeyValue) matching the key and value types of the map
+	pointer_free     bool
+	stats            bool
+	items_lock_stats *stats.InstrumentedPool // Set when the item locks are instrumented, wraps items_lock
+	batch_workers    int
+	unique_values    bool
 }
 
 // CreateOptions creates a new instance of SetBase with the default bucket size.
@@ -22,6 +33,14 @@ func CreateOptions[T any](opts ...options.Option[Options]) (Options, error) {
 		items_lock:  locks.NewPool(),
 		bucket_amount: 0,
 		bucket_amount_fn: nil,
+		weigher:          nil,
+		max_weight:       0,
+		on_evict:         nil,
+		pointer_free:     false,
+		stats:            false,
+		items_lock_stats: nil,
+		batch_workers:    1,
+		unique_values:    false,
 	}
 
 	err := options.Apply(&op, opts...)
@@ -57,6 +76,23 @@ func WithMaxBucketSize(size uint64) options.Option[Options] {
 func WithItemLocks(pool *locks.Pool) options.Option[Options] {
 	return options.NewFunction(func(option *Options) {
 		option.items_lock = pool
+		option.items_lock_stats = nil
+	})
+}
+
+// WithInstrumentedItemLocks sets the locks that the map will use, recording how long locking them waits, see [stats.InstrumentedPool.Hot]
+func WithInstrumentedItemLocks(pool *stats.InstrumentedPool) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.items_lock = pool.Pool()
+		option.items_lock_stats = pool
+	})
+}
+
+// WithConcurrency sizes the item locks for the amount of goroutines that write concurrently, see [stats.PoolSize]
+func WithConcurrency(concurrency int) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.items_lock = locks.NewPool(locks.WithSize(stats.PoolSize(concurrency, 0.25)))
+		option.items_lock_stats = nil
 	})
 }
 
@@ -80,3 +116,75 @@ func WithBucketFunction(calc func(capacity uint64) uint64) options.Option[Option
 		option.bucket_amount_fn = calc
 	})
 }
+
+// WithWeigher sets the weigher used to track the weight of the entries, see [EstimateWeigher] for a default
+func WithWeigher[K, V any](weigher Weigher[K, V]) options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.weigher = weigher
+	})
+}
+
+// WithMaxWeight bounds the total weight of the entries, once exceeded entries are evicted from the oldest bucket of the chain in slot order,
+// which is not the order they were added in. Requires [WithWeigher]
+func WithMaxWeight(weight uint64) options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.max_weight = weight
+	})
+}
+
+// WithEvictionHandler sets the function that receives the entries evicted to stay within [WithMaxWeight].
+// It is called after the entry is removed, while the item locks of both the evicted entry and the entry being added or grown are held,
+// so changes to the evicted key wait for the handler. The lock of the bucket chain is released by then, so inserts of other keys are not blocked.
+// It should not change the map, as that may wait on the held item locks
+func WithEvictionHandler[K comparable, V any](handler func(item KeyValue[K, V])) options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.on_evict = handler
+	})
+}
+
+// WithPointerFree stores the entries copied into byte arenas, referenced by offset, so the buckets hold no pointers for the garbage collector to scan.
+// Keys and values have to be strings or types without pointers, strings read from the map reference the arenas
+func WithPointerFree() options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.pointer_free = true
+	})
+}
+
+// WithStats records hits, misses, evictions and lock contention per bucket, reported by Stats on the map
+func WithStats() options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.stats = true
+	})
+}
+
+// WithBatchWorkers sets the amount of goroutines that process the buckets of a batch operation, such as [Bucketted.SetMany], in parallel
+func WithBatchWorkers(workers int) options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.batch_workers = max(workers, 1)
+	})
+}
+
+// WithUniqueValues keeps the values of a key in a [MultiMap] as a set, adding a value the key already has is ignored
+func WithUniqueValues() options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.unique_values = true
+	})
+}
+
+// withoutEviction returns an error if the options evict entries, for maps that keep state besides the entries that eviction would leave behind
+func (o Options) withoutEviction(kind string) error {
+	if o.max_weight > 0 || o.on_evict != nil {
+		return fmt.Errorf("%s does not support eviction, see WithMaxWeight and WithEvictionHandler", kind)
+	}
+
+	return nil
+}
+
+// split returns the options for one of amount buckets, dividing the weight budget over them
+func (o Options) split(amount uint64) Options {
+	if o.max_weight > 0 {
+		o.max_weight = max(o.max_weight/max(amount, 1), 1)
+	}
+
+	return o
+}
@@ -0,0 +1,88 @@
+package maps
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/daanv2/go-cache/pkg/arena"
+)
+
+// packed_header_size is the size of the header in front of every packed entry: the hash, the key length, and the value length
+const packed_header_size = 16
+
+// packed_absent is set in the value length of negative entries, see [KeyValue.Absent]
+const packed_absent uint32 = 1 << 31
+
+// packer copies entries into arenas, for maps storing their entries without pointers, see [WithPointerFree]
+type packer[K, V comparable] struct {
+	keys   arena.Codec[K]
+	values arena.Codec[V]
+}
+
+func newPacker[K, V comparable]() (*packer[K, V], error) {
+	keys, err := arena.NewCodec[K]()
+	if err != nil {
+		return nil, err
+	}
+	values, err := arena.NewCodec[V]()
+	if err != nil {
+		return nil, err
+	}
+
+	return &packer[K, V]{keys, values}, nil
+}
+
+// write copies the item into the arena, returning its handle
+func (p *packer[K, V]) write(a *arena.Arena, item KeyValue[K, V]) arena.Handle {
+	klen, vlen := p.keys.Len(item.Key), p.values.Len(item.Value)
+	h, data := a.Alloc(packed_header_size + klen + vlen)
+
+	binary.LittleEndian.PutUint64(data, item.Hash)
+	binary.LittleEndian.PutUint32(data[8:], uint32(klen))
+	flags := uint32(vlen)
+	if item.Absent {
+		flags |= packed_absent
+	}
+	binary.LittleEndian.PutUint32(data[12:], flags)
+	p.keys.Put(data[packed_header_size:], item.Key)
+	p.values.Put(data[packed_header_size+klen:], item.Value)
+
+	return h
+}
+
+// read returns the item the handle points at, strings reference the arena
+func (p *packer[K, V]) read(a *arena.Arena, h arena.Handle) KeyValue[K, V] {
+	data := a.Bytes(h)
+	klen := int(binary.LittleEndian.Uint32(data[8:]))
+	flags := binary.LittleEndian.Uint32(data[12:])
+	vlen := int(flags &^ packed_absent)
+
+	return KeyValue[K, V]{
+		Hash:   binary.LittleEndian.Uint64(data),
+		Key:    p.keys.Get(data[packed_header_size : packed_header_size+klen]),
+		Value:  p.values.Get(data[packed_header_size+klen : packed_header_size+klen+vlen]),
+		Absent: flags&packed_absent != 0,
+	}
+}
+
+// size returns the amount of bytes of the entry the handle points at
+func (p *packer[K, V]) size(a *arena.Arena, h arena.Handle) uint64 {
+	data := a.Bytes(h)
+	return packed_header_size + uint64(binary.LittleEndian.Uint32(data[8:])) + uint64(binary.LittleEndian.Uint32(data[12:])&^packed_absent)
+}
+
+// packedTable holds the handles of the slots of a [Fixed] and the arena they point into.
+// Compacting replaces the table as a whole, so readers always see handles and an arena that belong together.
+type packedTable struct {
+	handles []atomic.Uint64
+	arena   *arena.Arena
+	garbage uint64 // The amount of bytes of entries that were overwritten or removed
+}
+
+func newPackedTable(amount uint64, chunk_size int) *packedTable {
+	return &packedTable{
+		handles: make([]atomic.Uint64, amount),
+		arena:   arena.New(chunk_size),
+		garbage: 0,
+	}
+}
@@ -0,0 +1,90 @@
+package maps_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BuckettedMap_PointerFree(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](1000, test_util.CheapIntHasher[int](), maps.WithPointerFree())
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		require.True(t, col.Set(i, fmt.Sprint(i)))
+	}
+
+	// Overwriting many times compacts the arenas along the way
+	for round := range 20 {
+		for i := range 1000 {
+			require.False(t, col.Set(i, strings.Repeat(fmt.Sprint(round), i%50)))
+		}
+	}
+
+	for i := range 1000 {
+		v, ok := col.Get(i)
+		require.True(t, ok)
+		require.Equal(t, i, v.Key)
+		require.Equal(t, strings.Repeat("19", i%50), v.Value)
+	}
+
+	v, ok := col.Delete(10)
+	require.True(t, ok)
+	require.Equal(t, strings.Repeat("19", 10), v.Value)
+	_, ok = col.Get(10)
+	require.False(t, ok)
+
+	count := 0
+	for item := range col.Read() {
+		require.Equal(t, strings.Repeat("19", item.Key%50), item.Value)
+		count++
+	}
+	require.Equal(t, 999, count)
+
+	_, err = maps.NewBuckettedMap[int, *int](1000, test_util.CheapIntHasher[int](), maps.WithPointerFree())
+	require.Error(t, err)
+}
+
+func Test_BuckettedMap_PointerFree_Concurrent(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](1000, test_util.CheapIntHasher[int](), maps.WithPointerFree())
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for round := range 20 {
+				for i := range 250 {
+					key := w*250 + i
+					col.Set(key, fmt.Sprint(key, "-", round))
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for range 20 {
+			for item := range col.Read() {
+				require.True(t, strings.HasPrefix(item.Value, fmt.Sprint(item.Key, "-")), item)
+			}
+		}
+	}()
+	wg.Wait()
+
+	for key := range 1000 {
+		v, ok := col.Get(key)
+		require.True(t, ok)
+		require.Equal(t, fmt.Sprint(key, "-19"), v.Value)
+	}
+}
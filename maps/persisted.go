@@ -0,0 +1,428 @@
+package maps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stores"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// ErrPersistedClosed is returned when changing a [Persisted] map after it was closed
+var ErrPersistedClosed = errors.New("persisted map is closed")
+
+// WriteMode determines when changes to a [Persisted] map are written to its store
+type WriteMode uint8
+
+const (
+	// WriteThrough writes every change to the store before it is applied to the map
+	WriteThrough WriteMode = iota
+	// WriteBehind applies changes to the map and writes them to the store in batches in the background
+	WriteBehind
+)
+
+// PersistOptions are the options for a [Persisted] map.
+type PersistOptions struct {
+	mode           WriteMode
+	flush_interval time.Duration
+	batch_size     int
+	retries        int
+	retry_backoff  time.Duration
+	on_error       func(err error)
+}
+
+// CreatePersistOptions creates the options for a [Persisted] map, defaulting to [WriteThrough].
+func CreatePersistOptions(opts ...options.Option[PersistOptions]) (PersistOptions, error) {
+	op := PersistOptions{
+		mode:           WriteThrough,
+		flush_interval: time.Second,
+		batch_size:     1000,
+		retries:        3,
+		retry_backoff:  10 * time.Millisecond,
+		on_error:       nil,
+	}
+
+	err := options.Apply(&op, opts...)
+
+	return op, err
+}
+
+// WithWriteThrough makes every change be persisted before it is applied to the map
+func WithWriteThrough() options.Option[PersistOptions] {
+	return options.NewFunction(func(option *PersistOptions) {
+		option.mode = WriteThrough
+	})
+}
+
+// WithWriteBehind makes changes be persisted in the background, every interval or once batch size keys are dirty
+func WithWriteBehind(interval time.Duration, batch_size int) options.Option[PersistOptions] {
+	return options.NewFunctionE(func(option *PersistOptions) error {
+		if interval <= 0 {
+			return errors.New("flush interval has to be larger than 0")
+		}
+
+		option.mode = WriteBehind
+		option.flush_interval = interval
+		option.batch_size = max(batch_size, 1)
+		return nil
+	})
+}
+
+// WithRetries sets how many times a failed store operation is retried, and how long to wait between attempts
+func WithRetries(retries int, backoff time.Duration) options.Option[PersistOptions] {
+	return options.NewFunction(func(option *PersistOptions) {
+		option.retries = max(retries, 0)
+		option.retry_backoff = backoff
+	})
+}
+
+// WithFlushErrorHandler sets the function that receives errors from background flushes
+func WithFlushErrorHandler(handler func(err error)) options.Option[PersistOptions] {
+	return options.NewFunction(func(option *PersistOptions) {
+		option.on_error = handler
+	})
+}
+
+// pending is a change that has not been written to the store yet
+type pending[V any] struct {
+	value   V
+	deleted bool
+	version uint64
+}
+
+// Persisted is a [Bucketted] map that is backed by a [stores.Store].
+// Misses are loaded from the store, and changes are written to it according to the [WriteMode].
+type Persisted[K, V comparable] struct {
+	PersistOptions
+	data  *Bucketted[K, V]
+	store stores.Store[K, V]
+
+	dirty      map[K]pending[V]
+	version    uint64
+	closed     bool // Set by Close, guarded by the dirty lock so no change is marked after the last flush
+	dirty_lock sync.Mutex
+	flush_lock sync.Mutex
+	notify     chan struct{}
+	done       chan struct{}
+	workers    sync.WaitGroup
+	close_once sync.Once
+}
+
+// NewPersistedMap wraps the map with the store, when using [WithWriteBehind] a background flusher is started that is stopped by [Persisted.Close].
+func NewPersistedMap[K, V comparable](data *Bucketted[K, V], store stores.Store[K, V], opts ...options.Option[PersistOptions]) (*Persisted[K, V], error) {
+	if data == nil {
+		return nil, errors.New("map is nil")
+	}
+	if store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	base, err := CreatePersistOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Persisted[K, V]{
+		PersistOptions: base,
+		data:           data,
+		store:          store,
+		dirty:          make(map[K]pending[V]),
+		notify:         make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+
+	if base.mode == WriteBehind {
+		p.workers.Add(1)
+		go p.flusher()
+	}
+
+	return p, nil
+}
+
+// Get retrieves the value for the specified key, loading it from the store if the map does not have it.
+func (p *Persisted[K, V]) Get(ctx context.Context, key K) (KeyValue[K, V], bool, error) {
+	v, ok := p.data.Get(key)
+	if ok {
+		return v, true, nil
+	}
+
+	kv, bucket := p.data.locate(key)
+
+	// Hold the item lock while loading, so a concurrent set or delete is not overwritten by what the store had
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	// A negative entry holds no value, so it is replaced by what the store has
+	v, ok = bucket.Find(kv)
+	if ok && !v.Absent {
+		return v, true, nil
+	}
+	if p.isDeleted(key) {
+		return EmptyKeyValue[K, V](), false, nil
+	}
+
+	value, ok, err := p.store.Load(ctx, key)
+	if err != nil || !ok {
+		return EmptyKeyValue[K, V](), false, err
+	}
+
+	kv.Value = value
+	bucket.unsafeUpdateOrAdd(kv)
+	return kv, true, nil
+}
+
+// Set will add or update the value for the specified key. It returns true if the value was added, false if it was updated.
+// In [WriteThrough] mode the map is left unchanged if the store returns an error.
+func (p *Persisted[K, V]) Set(ctx context.Context, key K, value V) (bool, error) {
+	kv, bucket := p.data.locate(key)
+	kv.Value = value
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	if p.mode == WriteThrough {
+		if p.isClosed() {
+			return false, ErrPersistedClosed
+		}
+
+		err := p.retry(ctx, func() error { return p.store.Store(ctx, key, value) })
+		if err != nil {
+			return false, err
+		}
+
+		return bucket.unsafeUpdateOrAdd(kv), nil
+	}
+
+	if err := p.markDirty(key, pending[V]{value: value}); err != nil {
+		return false, err
+	}
+	return bucket.unsafeUpdateOrAdd(kv), nil
+}
+
+// Delete removes the value for the specified key from the map and the store. It returns the removed item and true if the map had it.
+// In [WriteThrough] mode the map is left unchanged if the store returns an error.
+func (p *Persisted[K, V]) Delete(ctx context.Context, key K) (KeyValue[K, V], bool, error) {
+	kv, bucket := p.data.locate(key)
+
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	if p.mode == WriteThrough {
+		if p.isClosed() {
+			return EmptyKeyValue[K, V](), false, ErrPersistedClosed
+		}
+
+		err := p.retry(ctx, func() error { return p.store.Delete(ctx, key) })
+		if err != nil {
+			return EmptyKeyValue[K, V](), false, err
+		}
+
+		old, ok := bucket.unsafeDelete(kv)
+		if !ok || old.Absent {
+			return EmptyKeyValue[K, V](), false, nil
+		}
+		return old, true, nil
+	}
+
+	if err := p.markDirty(key, pending[V]{deleted: true}); err != nil {
+		return EmptyKeyValue[K, V](), false, err
+	}
+	old, ok := bucket.unsafeDelete(kv)
+	if !ok || old.Absent {
+		return EmptyKeyValue[K, V](), false, nil
+	}
+	return old, true, nil
+}
+
+// Preload loads all the keys that the map does not have yet from the store in a single batch.
+func (p *Persisted[K, V]) Preload(ctx context.Context, keys []K) error {
+	missing := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := p.data.Get(key); !ok && !p.isDeleted(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	values, err := p.store.LoadBatch(ctx, missing)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		kv, bucket := p.data.locate(key)
+		kv.Value = value
+		p.addIfMissing(bucket, kv)
+	}
+
+	return nil
+}
+
+// Flush writes all pending changes to the store. Changes that keep failing after the retries stay pending and their errors are returned.
+func (p *Persisted[K, V]) Flush(ctx context.Context) error {
+	p.flush_lock.Lock()
+	defer p.flush_lock.Unlock()
+
+	p.dirty_lock.Lock()
+	batch := make(map[K]pending[V], len(p.dirty))
+	for key, change := range p.dirty {
+		batch[key] = change
+	}
+	p.dirty_lock.Unlock()
+
+	errs := make([]error, 0)
+	for key, change := range batch {
+		err := p.retry(ctx, func() error {
+			if change.deleted {
+				return p.store.Delete(ctx, key)
+			}
+
+			return p.store.Store(ctx, key, change.value)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("flushing %v: %w", key, err))
+			continue
+		}
+
+		// Only clear the key if no newer change came in while it was being written
+		p.dirty_lock.Lock()
+		if current, ok := p.dirty[key]; ok && current.version == change.version {
+			delete(p.dirty, key)
+		}
+		p.dirty_lock.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Pending returns the amount of keys that have changes not yet written to the store
+func (p *Persisted[K, V]) Pending() int {
+	p.dirty_lock.Lock()
+	defer p.dirty_lock.Unlock()
+
+	return len(p.dirty)
+}
+
+// Close stops the background flusher and writes the remaining pending changes to the store, later changes return [ErrPersistedClosed].
+func (p *Persisted[K, V]) Close(ctx context.Context) error {
+	p.close_once.Do(func() {
+		p.dirty_lock.Lock()
+		p.closed = true
+		p.dirty_lock.Unlock()
+
+		close(p.done)
+	})
+	p.workers.Wait()
+
+	return p.Flush(ctx)
+}
+
+// Read will return a sequence of all items in the map, items only in the store are not included
+func (p *Persisted[K, V]) Read() iter.Seq[KeyValue[K, V]] {
+	return p.data.Read()
+}
+
+// Range will iterate over all items in the map, items only in the store are not included
+func (p *Persisted[K, V]) Range(yield func(item KeyValue[K, V]) bool) {
+	p.data.Range(yield)
+}
+
+func (p *Persisted[K, V]) String() string {
+	return fmt.Sprintf("maps.Persisted[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (p *Persisted[K, V]) GoString() string {
+	return p.String()
+}
+
+// markDirty records the change to be flushed, unless the map is closed
+func (p *Persisted[K, V]) markDirty(key K, change pending[V]) error {
+	p.dirty_lock.Lock()
+	if p.closed {
+		p.dirty_lock.Unlock()
+		return ErrPersistedClosed
+	}
+	p.version++
+	change.version = p.version
+	p.dirty[key] = change
+	full := len(p.dirty) >= p.batch_size
+	p.dirty_lock.Unlock()
+
+	if full {
+		select {
+		case p.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (p *Persisted[K, V]) isClosed() bool {
+	p.dirty_lock.Lock()
+	defer p.dirty_lock.Unlock()
+
+	return p.closed
+}
+
+// addIfMissing adds the loaded item, unless the map got it or it got deleted in the meantime
+func (p *Persisted[K, V]) addIfMissing(bucket *GrowableMap[K, V], kv KeyValue[K, V]) {
+	item_lock := bucket.lockItem(kv.Hash)
+	defer item_lock.Unlock()
+
+	if v, ok := bucket.Find(kv); (ok && !v.Absent) || p.isDeleted(kv.Key) {
+		return
+	}
+
+	bucket.unsafeUpdateOrAdd(kv)
+}
+
+func (p *Persisted[K, V]) isDeleted(key K) bool {
+	p.dirty_lock.Lock()
+	defer p.dirty_lock.Unlock()
+
+	change, ok := p.dirty[key]
+	return ok && change.deleted
+}
+
+func (p *Persisted[K, V]) retry(ctx context.Context, call func() error) error {
+	err := call()
+	for attempt := 0; err != nil && attempt < p.retries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(p.retry_backoff):
+		}
+
+		err = call()
+	}
+
+	return err
+}
+
+func (p *Persisted[K, V]) flusher() {
+	defer p.workers.Done()
+
+	ticker := time.NewTicker(p.flush_interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		case <-p.notify:
+		}
+
+		if err := p.Flush(context.Background()); err != nil && p.on_error != nil {
+			p.on_error(err)
+		}
+	}
+}
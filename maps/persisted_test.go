@@ -0,0 +1,184 @@
+package maps_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/stores"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func persistedStores(t *testing.T) map[string]stores.Store[int, string] {
+	file, err := stores.OpenFile[int, string](filepath.Join(t.TempDir(), "store.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+
+	return map[string]stores.Store[int, string]{
+		"Memory": stores.NewMemory[int, string](),
+		"File":   file,
+	}
+}
+
+func Test_Persisted_WriteThrough(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range persistedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			data, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+			require.NoError(t, err)
+			col, err := maps.NewPersistedMap(data, store, maps.WithWriteThrough())
+			require.NoError(t, err)
+
+			for i := range 100 {
+				added, err := col.Set(ctx, i, "value")
+				require.NoError(t, err)
+				require.True(t, added)
+
+				v, ok, err := store.Load(ctx, i)
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, "value", v)
+			}
+
+			_, ok, err := col.Delete(ctx, 5)
+			require.NoError(t, err)
+			require.True(t, ok)
+			_, ok, err = store.Load(ctx, 5)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			// Misses are loaded from the store
+			require.NoError(t, store.Store(ctx, 500, "stored"))
+			v, ok, err := col.Get(ctx, 500)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "stored", v.Value)
+			_, ok = data.Get(500)
+			require.True(t, ok)
+
+			require.NoError(t, store.Store(ctx, 600, "a"))
+			require.NoError(t, store.Store(ctx, 601, "b"))
+			require.NoError(t, col.Preload(ctx, []int{600, 601, 602}))
+			_, ok = data.Get(601)
+			require.True(t, ok)
+			_, ok = data.Get(602)
+			require.False(t, ok)
+
+			require.NoError(t, col.Close(ctx))
+		})
+	}
+}
+
+func Test_Persisted_WriteBehind(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range persistedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			data, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+			require.NoError(t, err)
+			col, err := maps.NewPersistedMap(data, store, maps.WithWriteBehind(time.Hour, 1_000_000))
+			require.NoError(t, err)
+
+			// Repeated writes to the same key are coalesced
+			for i := range 100 {
+				for range 5 {
+					_, err := col.Set(ctx, i, "value")
+					require.NoError(t, err)
+				}
+			}
+			require.Equal(t, 100, col.Pending())
+
+			_, ok, err := store.Load(ctx, 1)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			// A pending delete is not resurrected from the store
+			require.NoError(t, col.Flush(ctx))
+			_, _, err = col.Delete(ctx, 1)
+			require.NoError(t, err)
+			_, ok, err = col.Get(ctx, 1)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			require.NoError(t, col.Close(ctx))
+			require.Equal(t, 0, col.Pending())
+
+			_, ok, err = store.Load(ctx, 1)
+			require.NoError(t, err)
+			require.False(t, ok)
+			v, ok, err := store.Load(ctx, 2)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "value", v)
+
+			// Changes after closing would never be flushed
+			_, err = col.Set(ctx, 2, "late")
+			require.ErrorIs(t, err, maps.ErrPersistedClosed)
+			_, _, err = col.Delete(ctx, 2)
+			require.ErrorIs(t, err, maps.ErrPersistedClosed)
+			require.Equal(t, 0, col.Pending())
+			item, _, _ := col.Get(ctx, 2)
+			require.Equal(t, "value", item.Value)
+		})
+	}
+}
+
+func Test_Persisted_WriteBehind_Background(t *testing.T) {
+	ctx := context.Background()
+	store := stores.NewMemory[int, string]()
+
+	data, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	col, err := maps.NewPersistedMap(data, store, maps.WithWriteBehind(time.Hour, 10))
+	require.NoError(t, err)
+	defer col.Close(ctx)
+
+	for i := range 10 {
+		_, err := col.Set(ctx, i, "value")
+		require.NoError(t, err)
+	}
+
+	// Reaching the batch size triggers a flush without waiting for the interval
+	require.Eventually(t, func() bool { return store.Len() == 10 }, time.Second, time.Millisecond)
+}
+
+type flakyStore struct {
+	*stores.Memory[int, string]
+	failures atomic.Int32
+}
+
+func (f *flakyStore) Store(ctx context.Context, key int, value string) error {
+	if f.failures.Add(-1) >= 0 {
+		return errors.New("store is unavailable")
+	}
+
+	return f.Memory.Store(ctx, key, value)
+}
+
+func Test_Persisted_Retries(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{Memory: stores.NewMemory[int, string]()}
+
+	data, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	col, err := maps.NewPersistedMap[int, string](data, store, maps.WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+
+	// Recovers within the retries
+	store.failures.Store(2)
+	_, err = col.Set(ctx, 1, "value")
+	require.NoError(t, err)
+
+	// Gives up and leaves the map untouched
+	store.failures.Store(3)
+	_, err = col.Set(ctx, 2, "value")
+	require.Error(t, err)
+	_, ok := data.Get(2)
+	require.False(t, ok)
+}
@@ -0,0 +1,127 @@
+package maps_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/stats"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/daanv2/go-locks"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BuckettedMap_Stats(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](
+		100,
+		test_util.CheapIntHasher[int](),
+		maps.WithBucketAmount(4),
+		maps.WithWeigher(maps.WeigherFunc[int, string](func(key int, value string) uint64 { return 1 })),
+		maps.WithMaxWeight(40),
+		maps.WithStats(),
+	)
+	require.NoError(t, err)
+
+	for i := range 100 {
+		col.Set(i, "value")
+	}
+	for i := range 100 {
+		col.Get(i)
+	}
+
+	s := col.Stats()
+	require.Equal(t, uint64(40), s.Hits)
+	require.Equal(t, uint64(60), s.Misses)
+	require.Equal(t, uint64(60), s.Evictions)
+	require.Len(t, s.BucketContention, 4)
+
+	// Without stats enabled nothing is recorded
+	plain, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	plain.Set(1, "value")
+	plain.Get(1)
+	require.Zero(t, plain.Stats().Hits)
+}
+
+func Test_BuckettedMap_Stats_Contention(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, int](100, test_util.CheapIntHasher[int](), maps.WithBucketAmount(1), maps.WithStats())
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10000 {
+				col.Set(i%4, w)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := col.Stats()
+	require.Equal(t, s.Contention, s.BucketContention[0])
+}
+
+func Test_Loading_Stats(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	loader := &countingLoader{}
+
+	col, err := maps.NewLoadingMap[int, string](100, test_util.CheapIntHasher[int](), loader.Load,
+		maps.WithTTL(time.Minute),
+		maps.WithClock(clock.Now),
+		maps.WithMapOptions(maps.WithStats()),
+	)
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err := col.Get(ctx, 1)
+		require.NoError(t, err)
+	}
+	clock.Advance(time.Minute)
+	_, err = col.Get(ctx, 1)
+	require.NoError(t, err)
+
+	loader.fail.Store(true)
+	_, err = col.Get(ctx, 2)
+	require.Error(t, err)
+
+	s := col.Stats()
+	require.Equal(t, uint64(2), s.Hits)
+	require.Equal(t, uint64(3), s.Misses)
+	require.Equal(t, uint64(1), s.Expirations)
+	require.Equal(t, uint64(3), s.Loads)
+	require.Equal(t, uint64(1), s.LoadErrors)
+	require.Equal(t, uint64(3), s.LoadLatency.Count)
+}
+
+func Test_BuckettedMap_InstrumentedItemLocks(t *testing.T) {
+	pool := stats.NewInstrumentedPool(locks.NewPool(locks.WithSize(10)))
+	a, err := maps.NewBuckettedMap[int, int](100, test_util.CheapIntHasher[int](), maps.WithInstrumentedItemLocks(pool), maps.WithStats())
+	require.NoError(t, err)
+	b, err := maps.NewBuckettedMap[int, int](100, test_util.CheapIntHasher[int](), maps.WithInstrumentedItemLocks(pool))
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				a.Set(i, w)
+				b.Set(i, w)
+			}
+		}()
+	}
+	wg.Wait()
+
+	acquired := uint64(0)
+	for _, stripe := range pool.Stripes() {
+		acquired += stripe.Acquired
+	}
+	require.Equal(t, uint64(16000), acquired)
+	require.LessOrEqual(t, a.Stats().Contention, uint64(16000))
+}
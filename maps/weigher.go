@@ -0,0 +1,70 @@
+package maps
+
+import (
+	"encoding"
+	"reflect"
+)
+
+// Weigher determines the weight of an entry, used to bound maps by something other than their amount of entries.
+type Weigher[K, V any] interface {
+	Weigh(key K, value V) uint64
+}
+
+var _ Weigher[struct{}, struct{}] = WeigherFunc[struct{}, struct{}](nil)
+
+// WeigherFunc is a function that implements [Weigher]
+type WeigherFunc[K, V any] func(key K, value V) uint64
+
+// Weigh implements Weigher.
+func (w WeigherFunc[K, V]) Weigh(key K, value V) uint64 {
+	return w(key, value)
+}
+
+// EstimateWeigher returns a [Weigher] that estimates the amount of bytes of the key and value with [EstimateSize]
+func EstimateWeigher[K, V any]() Weigher[K, V] {
+	return WeigherFunc[K, V](func(key K, value V) uint64 {
+		return EstimateSize(key) + EstimateSize(value)
+	})
+}
+
+// EstimateSize estimates the amount of bytes the value occupies, strings and slices count their contents,
+// [encoding.BinaryMarshaler] values count the length of their marshalled form, anything else counts its own size.
+func EstimateSize(value any) uint64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return uint64(reflect.TypeFor[string]().Size()) + uint64(len(v))
+	case []byte:
+		return uint64(reflect.TypeFor[[]byte]().Size()) + uint64(len(v))
+	case encoding.BinaryMarshaler:
+		data, err := v.MarshalBinary()
+		if err == nil {
+			return uint64(len(data))
+		}
+	}
+
+	rv := reflect.ValueOf(value)
+	size := uint64(rv.Type().Size())
+
+	switch rv.Kind() {
+	case reflect.String:
+		size += uint64(rv.Len())
+	case reflect.Slice:
+		elem := rv.Type().Elem()
+		switch elem.Kind() {
+		case reflect.String, reflect.Slice, reflect.Interface, reflect.Pointer:
+			for i := range rv.Len() {
+				size += EstimateSize(rv.Index(i).Interface())
+			}
+		default:
+			size += uint64(rv.Len()) * uint64(elem.Size())
+		}
+	case reflect.Pointer:
+		if !rv.IsNil() {
+			size += EstimateSize(rv.Elem().Interface())
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,135 @@
+package maps_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+type marshaller struct{ size int }
+
+func (m marshaller) MarshalBinary() ([]byte, error) {
+	return make([]byte, m.size), nil
+}
+
+func Test_EstimateSize(t *testing.T) {
+	require.EqualValues(t, 0, maps.EstimateSize(nil))
+	require.EqualValues(t, 8, maps.EstimateSize(uint64(1)))
+	require.EqualValues(t, 16+5, maps.EstimateSize("hello"))
+	require.EqualValues(t, 24+100, maps.EstimateSize(make([]byte, 100)))
+	require.EqualValues(t, 24+10*8, maps.EstimateSize(make([]int64, 10)))
+	require.EqualValues(t, 24+(16+1)+(16+2), maps.EstimateSize([]string{"a", "bb"}))
+	require.EqualValues(t, 1234, maps.EstimateSize(marshaller{1234}))
+}
+
+func Test_GrowableMap_MaxWeight(t *testing.T) {
+	col, err := maps.NewGrowableMap[int, string](
+		test_util.CheapIntHasher[int](),
+		maps.WithBucketSize(16),
+		maps.WithWeigher(maps.WeigherFunc[int, string](func(key int, value string) uint64 { return uint64(len(value)) })),
+		maps.WithMaxWeight(1000),
+	)
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		col.UpdateOrAdd(i, strings.Repeat("x", 10))
+		require.LessOrEqual(t, col.Weight(), uint64(1000))
+	}
+	require.EqualValues(t, 1000, col.Weight())
+
+	// Entries are evicted from the oldest bucket of the chain in slot order, which is not the order they were added in.
+	// The oldest bucket holds the first keys here, so those are the ones evicted
+	_, ok := col.Find(col.NewKeyValue(0, ""))
+	require.False(t, ok)
+	_, ok = col.Find(col.NewKeyValue(999, ""))
+	require.True(t, ok)
+
+	// Updates and deletes are accounted for
+	col.UpdateOrAdd(999, "")
+	require.EqualValues(t, 990, col.Weight())
+	col.Delete(998)
+	require.EqualValues(t, 980, col.Weight())
+
+	count := 0
+	for range col.Read() {
+		count++
+	}
+	require.Equal(t, 99, count)
+}
+
+func Test_GrowableMap_MaxWeight_Grow(t *testing.T) {
+	col, err := maps.NewGrowableMap[int, string](
+		test_util.CheapIntHasher[int](),
+		maps.WithBucketSize(16),
+		maps.WithWeigher(maps.WeigherFunc[int, string](func(key int, value string) uint64 { return uint64(len(value)) })),
+		maps.WithMaxWeight(100),
+	)
+	require.NoError(t, err)
+
+	for i := range 10 {
+		col.UpdateOrAdd(i, strings.Repeat("x", 10))
+	}
+	require.EqualValues(t, 100, col.Weight())
+
+	// Growing an existing value evicts others, but keeps the grown one
+	col.UpdateOrAdd(5, strings.Repeat("x", 50))
+	require.LessOrEqual(t, col.Weight(), uint64(100))
+	v, ok := col.Find(col.NewKeyValue(5, ""))
+	require.True(t, ok)
+	require.Len(t, v.Value, 50)
+}
+
+func Test_BuckettedMap_MaxWeight(t *testing.T) {
+	col, err := maps.NewBuckettedMap[int, string](
+		1000,
+		test_util.CheapIntHasher[int](),
+		maps.WithBucketAmount(10),
+		maps.WithWeigher(maps.EstimateWeigher[int, string]()),
+		maps.WithMaxWeight(64*1024),
+	)
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		col.Set(i, strings.Repeat("x", 1024))
+	}
+	require.LessOrEqual(t, col.Weight(), uint64(64*1024))
+	require.Greater(t, col.Weight(), uint64(32*1024))
+}
+
+func Test_MaxWeight_Validation(t *testing.T) {
+	_, err := maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithMaxWeight(100))
+	require.Error(t, err)
+
+	_, err = maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithWeigher(maps.EstimateWeigher[string, string]()))
+	require.Error(t, err)
+}
+
+func Test_BuckettedMap_EvictionHandler(t *testing.T) {
+	evicted := map[int]string{}
+	col, err := maps.NewBuckettedMap[int, string](
+		100,
+		test_util.CheapIntHasher[int](),
+		maps.WithBucketAmount(1),
+		maps.WithWeigher(maps.WeigherFunc[int, string](func(key int, value string) uint64 { return 1 })),
+		maps.WithMaxWeight(10),
+		maps.WithEvictionHandler(func(item maps.KeyValue[int, string]) { evicted[item.Key] = item.Value }),
+	)
+	require.NoError(t, err)
+
+	for i := range 100 {
+		col.Set(i, strings.Repeat("x", i))
+	}
+
+	require.Len(t, evicted, 90)
+	for k, v := range evicted {
+		require.Equal(t, strings.Repeat("x", k), v)
+		_, ok := col.Get(k)
+		require.False(t, ok)
+	}
+
+	_, err = maps.NewBuckettedMap[int, string](100, test_util.CheapIntHasher[int](), maps.WithEvictionHandler(func(item maps.KeyValue[string, string]) {}))
+	require.Error(t, err)
+}
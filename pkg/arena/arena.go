@@ -0,0 +1,61 @@
+package arena
+
+import "sync/atomic"
+
+// Handle points at bytes in an [Arena], the zero handle points at nothing
+type Handle uint64
+
+const offset_bits = 40
+
+// Arena is an append only list of byte chunks. Bytes are never changed once written, so readers need no locks,
+// writers are expected to be serialized by the caller.
+type Arena struct {
+	chunks     atomic.Pointer[[][]byte] // Replaced as a whole when a chunk is added
+	chunk_size int
+	used       int    // The amount of bytes used of the last chunk
+	size       uint64 // The amount of bytes allocated
+}
+
+// New creates an arena that allocates chunks of chunk size bytes, or larger for allocations that do not fit.
+func New(chunk_size int) *Arena {
+	a := &Arena{
+		chunk_size: max(chunk_size, 64),
+		used:       0,
+		size:       0,
+	}
+	a.chunks.Store(&[][]byte{})
+
+	return a
+}
+
+// Alloc reserves n bytes, the returned bytes have to be written before the handle is published to readers
+func (a *Arena) Alloc(n int) (Handle, []byte) {
+	chunks := *a.chunks.Load()
+	last := len(chunks) - 1
+	if last < 0 || len(chunks[last])-a.used < n {
+		// Copy the list, readers might still be using the old one
+		chunks = append(chunks[:len(chunks):len(chunks)], make([]byte, max(a.chunk_size, n)))
+		a.chunks.Store(&chunks)
+		a.used = 0
+		last++
+	}
+
+	offset := a.used
+	a.used += n
+	a.size += uint64(n)
+
+	return Handle(uint64(last+1)<<offset_bits | uint64(offset)), chunks[last][offset : offset+n : offset+n]
+}
+
+// Bytes returns the bytes from the handle to the end of its chunk
+func (a *Arena) Bytes(h Handle) []byte {
+	chunks := *a.chunks.Load()
+	chunk := chunks[(h>>offset_bits)-1]
+
+	return chunk[h&(1<<offset_bits-1):]
+}
+
+// Size returns the amount of bytes allocated
+func (a *Arena) Size() uint64 {
+	return a.size
+}
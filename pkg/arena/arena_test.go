@@ -0,0 +1,57 @@
+package arena_test
+
+import (
+	"testing"
+
+	"github.com/daanv2/go-cache/pkg/arena"
+	"github.com/stretchr/testify/require"
+)
+
+type point struct {
+	X, Y int32
+	Tag  [4]byte
+}
+
+type name string
+
+func Test_Arena(t *testing.T) {
+	a := arena.New(64)
+	handles := []arena.Handle{}
+
+	for i := range 100 {
+		h, data := a.Alloc(i % 80)
+		for j := range data {
+			data[j] = byte(i)
+		}
+		handles = append(handles, h)
+	}
+
+	for i, h := range handles {
+		require.NotZero(t, h)
+		data := a.Bytes(h)[:i%80]
+		for _, b := range data {
+			require.Equal(t, byte(i), b)
+		}
+	}
+}
+
+func Test_Codec(t *testing.T) {
+	strs, err := arena.NewCodec[name]()
+	require.NoError(t, err)
+	buf := make([]byte, strs.Len("hello"))
+	strs.Put(buf, "hello")
+	require.Equal(t, name("hello"), strs.Get(buf))
+	require.Equal(t, name(""), strs.Get(nil))
+
+	points, err := arena.NewCodec[point]()
+	require.NoError(t, err)
+	p := point{1, -2, [4]byte{'a', 'b', 'c', 'd'}}
+	buf = make([]byte, points.Len(p))
+	points.Put(buf, p)
+	require.Equal(t, p, points.Get(buf))
+
+	_, err = arena.NewCodec[*point]()
+	require.Error(t, err)
+	_, err = arena.NewCodec[struct{ S string }]()
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package arena
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Codec copies values into bytes and back, for strings and types without pointers.
+// Strings read back reference the bytes they were read from, those are never to be changed.
+type Codec[T any] struct {
+	str  bool
+	size int
+}
+
+// NewCodec returns the codec for T, an error is returned if T is not a string kind and holds pointers
+func NewCodec[T any]() (Codec[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.String {
+		return Codec[T]{str: true}, nil
+	}
+	if !pointerFree(t) {
+		return Codec[T]{}, fmt.Errorf("%s holds pointers, only strings and types without pointers can be stored in an arena", t)
+	}
+
+	return Codec[T]{size: int(t.Size())}, nil
+}
+
+func pointerFree(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return pointerFree(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if !pointerFree(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Len returns the amount of bytes the value occupies
+func (c Codec[T]) Len(value T) int {
+	if c.str {
+		return len(*(*string)(unsafe.Pointer(&value)))
+	}
+
+	return c.size
+}
+
+// Put copies the value into dst, which has to be [Codec.Len] bytes
+func (c Codec[T]) Put(dst []byte, value T) {
+	if c.str {
+		copy(dst, *(*string)(unsafe.Pointer(&value)))
+		return
+	}
+
+	copy(dst, unsafe.Slice((*byte)(unsafe.Pointer(&value)), c.size))
+}
+
+// Get reads the value back from the bytes it was put in
+func (c Codec[T]) Get(src []byte) T {
+	var value T
+	if c.str {
+		if len(src) > 0 {
+			*(*string)(unsafe.Pointer(&value)) = unsafe.String(&src[0], len(src))
+		}
+		return value
+	}
+
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&value)), c.size), src)
+	return value
+}
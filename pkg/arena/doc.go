@@ -0,0 +1,2 @@
+// arena stores values in large byte chunks referenced by handles, so collections holding the handles contain no pointers for the garbage collector to scan.
+package arena
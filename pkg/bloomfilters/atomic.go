@@ -0,0 +1,45 @@
+package bloomfilters
+
+import "sync/atomic"
+
+// Atomic is a [Cheap] bloom filter that can be read and written concurrently without locks
+type Atomic struct {
+	amount uint64          // Amount of items stored
+	words  []atomic.Uint64 // Bit sizes
+}
+
+func NewAtomic(amount uint64) *Atomic {
+	w := max(amount/size_uint64, 1) * 2
+	if (amount % size_uint64) != 0 {
+		w++
+	}
+
+	return &Atomic{
+		words:  make([]atomic.Uint64, w),
+		amount: amount,
+	}
+}
+
+func (c *Atomic) Has(hash uint64) bool {
+	return c.has(hash) || c.has(hash^diffuser)
+}
+
+func (c *Atomic) has(hash uint64) bool {
+	bucket, bit := index(hash, c.amount)
+
+	word := c.words[bucket].Load()
+	mask := uint64(1 << bit)
+	return (word & mask) == mask
+}
+
+func (c *Atomic) Set(hash uint64) {
+	c.set(hash)
+	c.set(hash ^ diffuser)
+}
+
+func (c *Atomic) set(hash uint64) {
+	bucket, bit := index(hash, c.amount)
+
+	v := uint64(1 << bit)
+	c.words[bucket].Or(v)
+}
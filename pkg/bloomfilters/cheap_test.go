@@ -53,3 +53,51 @@ func Test_Cheap_Duplicates(t *testing.T) {
 
 	require.Less(t, correct, 64)
 }
+
+func Test_Atomic(t *testing.T) {
+	amounts := []uint64{
+		64,
+		128,
+		127,
+		129,
+	}
+
+	for _, amount := range amounts {
+		t.Run(fmt.Sprintf("Set->Has(%v)", amount), func(t *testing.T) {
+			filter := bloomfilters.NewAtomic(uint64(amount))
+
+			for i := range amount * 2 {
+				filter.Set(i)
+
+				ok := filter.Has(i)
+				require.True(t, ok, i)
+			}
+		})
+	}
+}
+
+func Test_Counting(t *testing.T) {
+	filter := bloomfilters.NewCounting(64)
+
+	for i := range uint64(64) {
+		filter.Add(i)
+	}
+	filter.Add(3)
+
+	for i := range uint64(64) {
+		require.True(t, filter.Has(i), i)
+	}
+
+	filter.Reset()
+	require.False(t, filter.Has(10))
+
+	// Removed once for every time it was added
+	filter.Add(3)
+	filter.Add(3)
+	filter.Add(4)
+	filter.Remove(3)
+	require.True(t, filter.Has(3))
+	filter.Remove(3)
+	require.False(t, filter.Has(3))
+	require.True(t, filter.Has(4))
+}
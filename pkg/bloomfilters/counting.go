@@ -0,0 +1,57 @@
+package bloomfilters
+
+import "sync/atomic"
+
+// Counting is a bloom filter that also supports removing hashes, by counting how many hashes set every slot.
+// It can be read and written concurrently without locks.
+type Counting struct {
+	counts []atomic.Uint32
+}
+
+// NewCounting creates a Counting filter sized for amount hashes
+func NewCounting(amount uint64) *Counting {
+	return &Counting{
+		counts: make([]atomic.Uint32, max(amount*2, size_uint64)),
+	}
+}
+
+// Has returns false if the hash was never added, or removed as often as it was added
+func (c *Counting) Has(hash uint64) bool {
+	a, b := c.slots(hash)
+	return c.counts[a].Load() > 0 && c.counts[b].Load() > 0
+}
+
+// Add adds the hash to the filter
+func (c *Counting) Add(hash uint64) {
+	a, b := c.slots(hash)
+	c.counts[a].Add(1)
+	c.counts[b].Add(1)
+}
+
+// Remove removes the hash from the filter, it is expected to have been added before
+func (c *Counting) Remove(hash uint64) {
+	a, b := c.slots(hash)
+	c.decrement(a)
+	c.decrement(b)
+}
+
+func (c *Counting) decrement(slot uint64) {
+	for {
+		v := c.counts[slot].Load()
+		if v == 0 || c.counts[slot].CompareAndSwap(v, v-1) {
+			return
+		}
+	}
+}
+
+// Reset removes all hashes from the filter
+func (c *Counting) Reset() {
+	for i := range c.counts {
+		c.counts[i].Store(0)
+	}
+}
+
+func (c *Counting) slots(hash uint64) (uint64, uint64) {
+	amount := uint64(len(c.counts))
+	return hash % amount, (hash ^ diffuser) % amount
+}
@@ -0,0 +1,111 @@
+package hash
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+var _ Hasher[string] = &ComparableHasher[string]{}
+
+// ComparableHasher hashes any comparable value with [maphash], strings and integers directly, other types through reflection.
+// The seed is random per hasher, so hashes differ between processes and should not be persisted.
+// Keys holding a NaN are not supported, as NaN is not equal to itself.
+type ComparableHasher[T comparable] struct {
+	seed maphash.Seed
+}
+
+// NewComparableHasher creates a new ComparableHasher with a random seed
+func NewComparableHasher[T comparable]() *ComparableHasher[T] {
+	return &ComparableHasher[T]{maphash.MakeSeed()}
+}
+
+// Hash implements Hasher.
+func (c *ComparableHasher[T]) Hash(item T) uint64 {
+	switch v := any(item).(type) {
+	case string:
+		return maphash.String(c.seed, v)
+	case int:
+		return c.integer(uint64(v))
+	case int64:
+		return c.integer(uint64(v))
+	case uint64:
+		return c.integer(v)
+	case uint32:
+		return c.integer(uint64(v))
+	case int32:
+		return c.integer(uint64(v))
+	}
+
+	h := maphash.Hash{}
+	h.SetSeed(c.seed)
+	writeValue(&h, reflect.ValueOf(item))
+	return h.Sum64()
+}
+
+func (c *ComparableHasher[T]) integer(v uint64) uint64 {
+	b := [8]byte{}
+	binary.LittleEndian.PutUint64(b[:], v)
+	return maphash.Bytes(c.seed, b[:])
+}
+
+// writeValue writes the parts of the value that are compared by ==
+func writeValue(h *maphash.Hash, v reflect.Value) {
+	b := [8]byte{}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			_ = h.WriteByte(1)
+		} else {
+			_ = h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(b[:], uint64(v.Int()))
+		_, _ = h.Write(b[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(b[:], v.Uint())
+		_, _ = h.Write(b[:])
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(b[:], floatBits(v.Float()))
+		_, _ = h.Write(b[:])
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		binary.LittleEndian.PutUint64(b[:], floatBits(real(c)))
+		_, _ = h.Write(b[:])
+		binary.LittleEndian.PutUint64(b[:], floatBits(imag(c)))
+		_, _ = h.Write(b[:])
+	case reflect.String:
+		binary.LittleEndian.PutUint64(b[:], uint64(v.Len()))
+		_, _ = h.Write(b[:])
+		_, _ = h.WriteString(v.String())
+	case reflect.Array:
+		for i := range v.Len() {
+			writeValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			writeValue(h, v.Field(i))
+		}
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		binary.LittleEndian.PutUint64(b[:], uint64(v.Pointer()))
+		_, _ = h.Write(b[:])
+	case reflect.Interface:
+		if v.IsNil() {
+			_ = h.WriteByte(0)
+			return
+		}
+		_, _ = h.WriteString(v.Elem().Type().String())
+		writeValue(h, v.Elem())
+	}
+}
+
+// floatBits returns the bits of the float, with -0 as +0 since they are equal
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+
+	return math.Float64bits(f)
+}
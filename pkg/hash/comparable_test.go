@@ -0,0 +1,33 @@
+package hash_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/stretchr/testify/require"
+)
+
+type compositeKey struct {
+	Name  string
+	ID    int
+	Inner any
+}
+
+func Test_ComparableHasher(t *testing.T) {
+	strs := hash.NewComparableHasher[string]()
+	require.Equal(t, strs.Hash("a"), strs.Hash("a"))
+	require.NotEqual(t, strs.Hash("a"), strs.Hash("b"))
+
+	keys := hash.NewComparableHasher[compositeKey]()
+	require.Equal(t, keys.Hash(compositeKey{"a", 1, 2}), keys.Hash(compositeKey{"a", 1, 2}))
+	require.NotEqual(t, keys.Hash(compositeKey{"a", 1, 2}), keys.Hash(compositeKey{"a", 2, 2}))
+	require.NotEqual(t, keys.Hash(compositeKey{"ab", 1, nil}), keys.Hash(compositeKey{"a", 1, "b"}))
+
+	// Equal under ==, so they need equal hashes
+	floats := hash.NewComparableHasher[float64]()
+	require.Equal(t, floats.Hash(0.0), floats.Hash(math.Copysign(0, -1)))
+	require.NotEqual(t, floats.Hash(1.0), floats.Hash(-1.0))
+	complexes := hash.NewComparableHasher[complex128]()
+	require.Equal(t, complexes.Hash(complex(0, 1)), complexes.Hash(complex(math.Copysign(0, -1), 1)))
+}
@@ -0,0 +1,102 @@
+package probing
+
+import "math/bits"
+
+const (
+	// GroupSize is the amount of slots described by a single control word
+	GroupSize = 8
+
+	// Empty marks a slot that has never been used, lookups stop at groups that contain one
+	Empty uint8 = 0b1000_0000
+	// Deleted marks a slot that used to hold an item, it can be reused but lookups continue past it
+	Deleted uint8 = 0b1111_1110
+	// Sentinel marks padding slots past the end of the bucket, they never match and can not be used
+	Sentinel uint8 = 0b1111_1111
+
+	lsb uint64 = 0x0101010101010101
+	msb uint64 = 0x8080808080808080
+)
+
+// Groups returns the amount of groups needed for the amount of slots
+func Groups(slots uint64) uint64 {
+	return (slots + GroupSize - 1) / GroupSize
+}
+
+// NewControl creates the control words for the amount of slots, all empty, with the padding marked as sentinels
+func NewControl(slots uint64) []uint64 {
+	words := make([]uint64, Groups(slots))
+	for i := range words {
+		words[i] = lsb * uint64(Empty)
+	}
+
+	if pad := slots % GroupSize; pad != 0 {
+		last := len(words) - 1
+		for slot := pad; slot < GroupSize; slot++ {
+			words[last] = Set(words[last], slot, Sentinel)
+		}
+	}
+
+	return words
+}
+
+// H1 returns the part of the hash used to select the group to start probing at
+func H1(hash uint64) uint64 {
+	return hash >> 7
+}
+
+// H2 returns the 7 bits of the hash stored in the control byte of a filled slot
+func H2(hash uint64) uint8 {
+	return uint8(hash & 0x7f)
+}
+
+// Start returns the group that a probe for the hash starts at
+func Start(hash, groups uint64) uint64 {
+	return H1(hash) % groups
+}
+
+// Set returns the control word with the control byte of the slot replaced
+func Set(word uint64, slot uint64, ctrl uint8) uint64 {
+	shift := slot * 8
+	return (word &^ (0xff << shift)) | (uint64(ctrl) << shift)
+}
+
+// Get returns the control byte of the slot
+func Get(word uint64, slot uint64) uint8 {
+	return uint8(word >> (slot * 8))
+}
+
+// MatchH2 returns the slots whose control byte might hold the h2, false positives are possible so the items still have to be compared
+func MatchH2(word uint64, h2 uint8) Mask {
+	x := word ^ (lsb * uint64(h2))
+	return Mask((x - lsb) &^ x & msb)
+}
+
+// MatchEmpty returns the slots that are empty
+func MatchEmpty(word uint64) Mask {
+	// Empty is the only control byte with the high bit set and the second highest bit clear
+	return Mask(word &^ (word << 1) & msb)
+}
+
+// MatchFree returns the slots that are empty or deleted, and can be used to insert an item
+func MatchFree(word uint64) Mask {
+	// Empty and deleted are the only control bytes with the high bit set and the lowest bit clear
+	return Mask(word &^ (word << 7) & msb)
+}
+
+// Mask is a set of slots in a group, as returned by the match functions
+type Mask uint64
+
+// Any returns true if any slot is in the mask
+func (m Mask) Any() bool {
+	return m != 0
+}
+
+// First returns the first slot in the mask, only valid if [Mask.Any] is true
+func (m Mask) First() uint64 {
+	return uint64(bits.TrailingZeros64(uint64(m))) / 8
+}
+
+// Next returns the mask without its first slot
+func (m Mask) Next() Mask {
+	return m & (m - 1)
+}
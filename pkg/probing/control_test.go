@@ -0,0 +1,53 @@
+package probing_test
+
+import (
+	"testing"
+
+	"github.com/daanv2/go-cache/pkg/probing"
+	"github.com/stretchr/testify/require"
+)
+
+func slots(m probing.Mask) []uint64 {
+	result := []uint64{}
+	for ; m.Any(); m = m.Next() {
+		result = append(result, m.First())
+	}
+
+	return result
+}
+
+func Test_NewControl(t *testing.T) {
+	words := probing.NewControl(11)
+	require.Len(t, words, 2)
+
+	require.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7}, slots(probing.MatchEmpty(words[0])))
+	require.Equal(t, []uint64{0, 1, 2}, slots(probing.MatchEmpty(words[1])))
+	require.Equal(t, []uint64{0, 1, 2}, slots(probing.MatchFree(words[1])))
+	require.Equal(t, probing.Sentinel, probing.Get(words[1], 5))
+}
+
+func Test_Match(t *testing.T) {
+	word := probing.NewControl(8)[0]
+	word = probing.Set(word, 1, probing.H2(0x12))
+	word = probing.Set(word, 3, probing.Deleted)
+	word = probing.Set(word, 4, probing.H2(0x12))
+	word = probing.Set(word, 6, probing.H2(0x7f))
+	word = probing.Set(word, 7, probing.Sentinel)
+
+	require.Subset(t, slots(probing.MatchH2(word, probing.H2(0x12))), []uint64{1, 4})
+	require.Contains(t, slots(probing.MatchH2(word, probing.H2(0x7f))), uint64(6))
+	require.Equal(t, []uint64{0, 2, 5}, slots(probing.MatchEmpty(word)))
+	require.Equal(t, []uint64{0, 2, 3, 5}, slots(probing.MatchFree(word)))
+}
+
+func Test_MatchH2_All(t *testing.T) {
+	for h2 := range uint8(0x80) {
+		for slot := range uint64(probing.GroupSize) {
+			word := probing.Set(probing.NewControl(8)[0], slot, h2)
+
+			require.Contains(t, slots(probing.MatchH2(word, h2)), slot)
+			require.NotContains(t, slots(probing.MatchEmpty(word)), slot)
+			require.NotContains(t, slots(probing.MatchFree(word)), slot)
+		}
+	}
+}
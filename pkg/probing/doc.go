@@ -0,0 +1,6 @@
+// probing provides SwissTable style control bytes, used to probe fixed size buckets in groups of 8 slots.
+//
+// Every slot has a control byte, that is either empty, deleted, a sentinel for padding, or holds 7 bits of the hash of the item in the slot.
+// A group of 8 control bytes is stored in a single uint64, so a whole group can be matched against a hash at once.
+// Lookups stop at the first group that has an empty slot, since inserts always fill the first group with room in the probe sequence.
+package probing
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Counters records the statistics of a single bucket of a collection, every method can be called concurrently.
+// A nil Counters records nothing, so collections without stats enabled pay no more than a nil check.
+type Counters struct {
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	negatives    atomic.Uint64
+	loads        atomic.Uint64
+	load_errors  atomic.Uint64
+	evictions    atomic.Uint64
+	expirations  atomic.Uint64
+	contention   atomic.Uint64
+	load_latency Histogram
+}
+
+// NewCounters creates a new Counters with every count at 0
+func NewCounters() *Counters {
+	return &Counters{}
+}
+
+// Hit records a lookup that found its key
+func (c *Counters) Hit() {
+	if c != nil {
+		c.hits.Add(1)
+	}
+}
+
+// Miss records a lookup that did not find its key
+func (c *Counters) Miss() {
+	if c != nil {
+		c.misses.Add(1)
+	}
+}
+
+// NegativeHit records a lookup that found its key known to be absent, which is neither a hit nor a miss
+func (c *Counters) NegativeHit() {
+	if c != nil {
+		c.negatives.Add(1)
+	}
+}
+
+// Load records a call to a loader that took the duration, and if it failed
+func (c *Counters) Load(took time.Duration, err error) {
+	if c == nil {
+		return
+	}
+
+	c.loads.Add(1)
+	if err != nil {
+		c.load_errors.Add(1)
+	}
+	c.load_latency.Observe(took)
+}
+
+// Evict records an entry removed to stay within the weight budget
+func (c *Counters) Evict() {
+	if c != nil {
+		c.evictions.Add(1)
+	}
+}
+
+// Expire records an entry that was found past its expiry
+func (c *Counters) Expire() {
+	if c != nil {
+		c.expirations.Add(1)
+	}
+}
+
+// Contended records a lock that was held by another goroutine when it was needed
+func (c *Counters) Contended() {
+	if c != nil {
+		c.contention.Add(1)
+	}
+}
+
+// Stats returns the current counts, with the contention of this bucket as the only bucket
+func (c *Counters) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	contention := c.contention.Load()
+	return Stats{
+		Hits:             c.hits.Load(),
+		Misses:           c.misses.Load(),
+		NegativeHits:     c.negatives.Load(),
+		Loads:            c.loads.Load(),
+		LoadErrors:       c.load_errors.Load(),
+		Evictions:        c.evictions.Load(),
+		Expirations:      c.expirations.Load(),
+		Contention:       contention,
+		LoadLatency:      c.load_latency.Snapshot(),
+		BucketContention: []uint64{contention},
+	}
+}
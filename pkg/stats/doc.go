@@ -0,0 +1,3 @@
+// stats is the package that records how the collections are used, such as hits, misses, loads and lock contention.
+// Collections created with stats enabled report a [Stats] snapshot, which an [Exporter] serves in the Prometheus text format or through expvar.
+package stats
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Exporter serves the statistics of registered collections, in the Prometheus text format through [Exporter.ServeHTTP] or through expvar, see [Exporter.Publish].
+type Exporter struct {
+	sources map[string]Source
+	lock    sync.RWMutex
+}
+
+// NewExporter creates a new Exporter without any collections
+func NewExporter() *Exporter {
+	return &Exporter{
+		sources: make(map[string]Source),
+		lock:    sync.RWMutex{},
+	}
+}
+
+// Register adds the collection under the name, which is used as the cache label of its metrics
+func (e *Exporter) Register(name string, source Source) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, ok := e.sources[name]; ok {
+		return fmt.Errorf("a collection is already registered as %q", name)
+	}
+
+	e.sources[name] = source
+	return nil
+}
+
+// Unregister removes the collection with the name
+func (e *Exporter) Unregister(name string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.sources, name)
+}
+
+// Snapshot returns the statistics of every registered collection by name
+func (e *Exporter) Snapshot() map[string]Stats {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	result := make(map[string]Stats, len(e.sources))
+	for name, source := range e.sources {
+		result[name] = source.Stats()
+	}
+
+	return result
+}
+
+// Publish exposes the snapshot as an expvar variable with the name, like [expvar.Publish] it panics if the name is already in use
+func (e *Exporter) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return e.Snapshot() }))
+}
+
+// ServeHTTP writes the statistics in the Prometheus text format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := e.WritePrometheus(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type counter struct {
+	name  string
+	help  string
+	value func(s Stats) uint64
+}
+
+var counters = []counter{
+	{"gocache_hits_total", "Lookups that found their key.", func(s Stats) uint64 { return s.Hits }},
+	{"gocache_misses_total", "Lookups that did not find their key.", func(s Stats) uint64 { return s.Misses }},
+	{"gocache_negative_hits_total", "Lookups that found their key known to be absent.", func(s Stats) uint64 { return s.NegativeHits }},
+	{"gocache_loads_total", "Calls to the loader, including failed ones.", func(s Stats) uint64 { return s.Loads }},
+	{"gocache_load_errors_total", "Calls to the loader that failed.", func(s Stats) uint64 { return s.LoadErrors }},
+	{"gocache_evictions_total", "Entries removed to stay within the weight budget.", func(s Stats) uint64 { return s.Evictions }},
+	{"gocache_expirations_total", "Entries found past their expiry.", func(s Stats) uint64 { return s.Expirations }},
+	{"gocache_contention_total", "Item locks that were held by another goroutine when needed.", func(s Stats) uint64 { return s.Contention }},
+}
+
+// WritePrometheus writes the statistics of every registered collection in the Prometheus text format
+func (e *Exporter) WritePrometheus(w io.Writer) error {
+	snapshot := e.Snapshot()
+	names := slices.Sorted(maps.Keys(snapshot))
+	out := bufio.NewWriter(w)
+
+	for _, c := range counters {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, name := range names {
+			fmt.Fprintf(out, "%s{cache=\"%s\"} %d\n", c.name, escape(name), c.value(snapshot[name]))
+		}
+	}
+
+	fmt.Fprint(out, "# HELP gocache_load_duration_seconds How long the calls to the loader took.\n# TYPE gocache_load_duration_seconds histogram\n")
+	for _, name := range names {
+		latency := snapshot[name].LoadLatency
+		cumulative := uint64(0)
+		for i, count := range latency.Counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(LatencyBounds) {
+				le = strconv.FormatFloat(LatencyBounds[i].Seconds(), 'g', -1, 64)
+			}
+			fmt.Fprintf(out, "gocache_load_duration_seconds_bucket{cache=\"%s\",le=\"%s\"} %d\n", escape(name), le, cumulative)
+		}
+		fmt.Fprintf(out, "gocache_load_duration_seconds_sum{cache=\"%s\"} %s\n", escape(name), strconv.FormatFloat(latency.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(out, "gocache_load_duration_seconds_count{cache=\"%s\"} %d\n", escape(name), latency.Count)
+	}
+
+	fmt.Fprint(out, "# HELP gocache_bucket_contention_total Item locks that were held by another goroutine when needed, per bucket.\n# TYPE gocache_bucket_contention_total counter\n")
+	for _, name := range names {
+		for i, count := range snapshot[name].BucketContention {
+			fmt.Fprintf(out, "gocache_bucket_contention_total{cache=\"%s\",bucket=\"%d\"} %d\n", escape(name), i, count)
+		}
+	}
+
+	return out.Flush()
+}
+
+var label_escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes the label value for the Prometheus text format
+func escape(value string) string {
+	return label_escaper.Replace(value)
+}
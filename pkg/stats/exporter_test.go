@@ -0,0 +1,75 @@
+package stats_test
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/pkg/stats"
+	"github.com/stretchr/testify/require"
+)
+
+type source stats.Stats
+
+func (s source) Stats() stats.Stats {
+	return stats.Stats(s)
+}
+
+func Test_Exporter_Prometheus(t *testing.T) {
+	counters := stats.NewCounters()
+	counters.Hit()
+	counters.Hit()
+	counters.Miss()
+	counters.NegativeHit()
+	counters.Contended()
+	counters.Load(2*time.Millisecond, nil)
+	counters.Load(20*time.Second, errors.New("failed"))
+
+	snapshot := counters.Stats()
+	require.Equal(t, uint64(2), snapshot.Hits)
+	require.Equal(t, uint64(1), snapshot.NegativeHits)
+	require.InDelta(t, 2.0/4.0, snapshot.HitRatio(), 0.001)
+	require.Equal(t, uint64(1), snapshot.LoadErrors)
+	require.Equal(t, uint64(2), snapshot.LoadLatency.Count)
+
+	exporter := stats.NewExporter()
+	require.NoError(t, exporter.Register("users", counters))
+	require.NoError(t, exporter.Register(`with "quotes"`, source{Misses: 5}))
+	require.Error(t, exporter.Register("users", counters))
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	out := string(body)
+	require.Contains(t, out, "# TYPE gocache_hits_total counter\n")
+	require.Contains(t, out, `gocache_hits_total{cache="users"} 2`+"\n")
+	require.Contains(t, out, `gocache_misses_total{cache="with \"quotes\""} 5`+"\n")
+	require.Contains(t, out, `gocache_negative_hits_total{cache="users"} 1`+"\n")
+	require.Contains(t, out, `gocache_load_duration_seconds_bucket{cache="users",le="0.001"} 0`+"\n")
+	require.Contains(t, out, `gocache_load_duration_seconds_bucket{cache="users",le="0.005"} 1`+"\n")
+	require.Contains(t, out, `gocache_load_duration_seconds_bucket{cache="users",le="10"} 1`+"\n")
+	require.Contains(t, out, `gocache_load_duration_seconds_bucket{cache="users",le="+Inf"} 2`+"\n")
+	require.Contains(t, out, `gocache_load_duration_seconds_count{cache="users"} 2`+"\n")
+	require.Contains(t, out, `gocache_bucket_contention_total{cache="users",bucket="0"} 1`+"\n")
+
+	exporter.Unregister(`with "quotes"`)
+	require.NotContains(t, exporter.Snapshot(), `with "quotes"`)
+}
+
+func Test_Exporter_Expvar(t *testing.T) {
+	counters := stats.NewCounters()
+	counters.Miss()
+
+	exporter := stats.NewExporter()
+	require.NoError(t, exporter.Register("users", counters))
+	exporter.Publish("test_exporter_expvar")
+
+	v := expvar.Get("test_exporter_expvar")
+	require.NotNil(t, v)
+	require.Contains(t, v.String(), `"Misses":1`)
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBounds are the upper bounds of the buckets of a [Histogram], an implicit last bucket holds anything slower
+var LatencyBounds = [...]time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Histogram counts durations into the buckets of [LatencyBounds], it can be observed concurrently
+type Histogram struct {
+	counts [len(LatencyBounds) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+// Observe adds the duration to the bucket it falls in
+func (h *Histogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(LatencyBounds) && d > LatencyBounds[i] {
+		i++
+	}
+
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Snapshot returns the current counts of the histogram
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+		s.Count += s.Counts[i]
+	}
+	s.Sum = time.Duration(h.sum.Load())
+
+	return s
+}
+
+// HistogramSnapshot is the state of a [Histogram], Counts[i] holds the durations up to LatencyBounds[i] that are larger than the previous bound
+type HistogramSnapshot struct {
+	Counts [len(LatencyBounds) + 1]uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// Add returns the sum of both snapshots
+func (h HistogramSnapshot) Add(other HistogramSnapshot) HistogramSnapshot {
+	for i := range h.Counts {
+		h.Counts[i] += other.Counts[i]
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+
+	return h
+}
+
+// Mean returns the average duration, 0 without any durations
+func (h HistogramSnapshot) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
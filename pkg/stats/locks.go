@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/daanv2/go-locks"
+)
+
+// recent_hashes is the amount of contended hashes remembered per stripe
+const recent_hashes = 4
+
+// InstrumentedPool wraps a [locks.Pool], recording per lock stripe how often locking it had to wait, for how long, and for which hashes.
+// Collections given the same InstrumentedPool share both the locks and the diagnostics.
+type InstrumentedPool struct {
+	pool    *locks.Pool
+	stripes []stripe
+}
+
+// stripe records the locking of a single lock of the pool
+type stripe struct {
+	acquired  atomic.Uint64
+	contended atomic.Uint64
+	wait      atomic.Int64
+	hashes    [recent_hashes]atomic.Uint64 // The last hashes that had to wait, as a ring
+	next      atomic.Uint64
+}
+
+// NewInstrumentedPool creates a new InstrumentedPool around the pool
+func NewInstrumentedPool(pool *locks.Pool) *InstrumentedPool {
+	return &InstrumentedPool{
+		pool:    pool,
+		stripes: make([]stripe, pool.Len()),
+	}
+}
+
+// Pool returns the wrapped pool
+func (p *InstrumentedPool) Pool() *locks.Pool {
+	return p.pool
+}
+
+// Lock locks the lock of the hash, returning it and if it had to wait because another goroutine held it
+func (p *InstrumentedPool) Lock(hash uint64) (*sync.Mutex, bool) {
+	lock := p.pool.GetLock(hash)
+	// The same index the pool uses to pick the lock
+	s := &p.stripes[hash%uint64(len(p.stripes))]
+	s.acquired.Add(1)
+
+	if lock.TryLock() {
+		return lock, false
+	}
+
+	start := time.Now()
+	lock.Lock()
+	s.wait.Add(int64(time.Since(start)))
+	s.contended.Add(1)
+	s.hashes[(s.next.Add(1)-1)%recent_hashes].Store(hash)
+
+	return lock, true
+}
+
+// StripeStats is a snapshot of the locking of a single lock of an [InstrumentedPool]
+type StripeStats struct {
+	Stripe    int           // The index of the lock in the pool
+	Acquired  uint64        // How often the lock was taken
+	Contended uint64        // How often taking the lock had to wait
+	Wait      time.Duration // The total time spent waiting for the lock
+	Hashes    []uint64      // The last hashes that had to wait, colliding hashes show up here together
+}
+
+// Stripes returns a snapshot of every lock of the pool, in the order of the pool
+func (p *InstrumentedPool) Stripes() []StripeStats {
+	result := make([]StripeStats, 0, len(p.stripes))
+	for i := range p.stripes {
+		s := &p.stripes[i]
+		stats := StripeStats{
+			Stripe:    i,
+			Acquired:  s.acquired.Load(),
+			Contended: s.contended.Load(),
+			Wait:      time.Duration(s.wait.Load()),
+			Hashes:    nil,
+		}
+
+		seen := min(s.next.Load(), recent_hashes)
+		for j := range seen {
+			stats.Hashes = append(stats.Hashes, s.hashes[j].Load())
+		}
+
+		result = append(result, stats)
+	}
+
+	return result
+}
+
+// Hot returns up to n stripes that had to wait, the ones that waited the longest first
+func (p *InstrumentedPool) Hot(n int) []StripeStats {
+	stripes := slices.DeleteFunc(p.Stripes(), func(s StripeStats) bool { return s.Contended == 0 })
+	slices.SortFunc(stripes, func(a, b StripeStats) int {
+		return cmp.Or(cmp.Compare(b.Wait, a.Wait), cmp.Compare(b.Contended, a.Contended))
+	})
+
+	return stripes[:min(n, len(stripes))]
+}
+
+// Contention returns the share of lockings that had to wait, 0 if nothing was locked yet.
+// A high share means the pool is too small for the concurrency, see [PoolSize]
+func (p *InstrumentedPool) Contention() float64 {
+	acquired, contended := uint64(0), uint64(0)
+	for i := range p.stripes {
+		acquired += p.stripes[i].acquired.Load()
+		contended += p.stripes[i].contended.Load()
+	}
+	if acquired == 0 {
+		return 0
+	}
+
+	return float64(contended) / float64(acquired)
+}
+
+// PoolSize returns the amount of locks for the amount of goroutines that write concurrently,
+// so that two of them contend for the same lock with a chance of about collision, such as 0.25
+func PoolSize(concurrency int, collision float64) int {
+	if collision <= 0 || collision > 1 {
+		collision = 0.25
+	}
+
+	return max(int(float64(max(concurrency, 1))/collision), 10)
+}
@@ -0,0 +1,49 @@
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/pkg/stats"
+	"github.com/daanv2/go-locks"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InstrumentedPool_Hot(t *testing.T) {
+	pool := stats.NewInstrumentedPool(locks.NewPool(locks.WithSize(16)))
+	require.Zero(t, pool.Contention())
+
+	// 3 and 19 collide on the same stripe
+	held := pool.Pool().GetLock(3)
+	held.Lock()
+
+	contended := make(chan bool, 1)
+	go func() {
+		lock, waited := pool.Lock(19)
+		lock.Unlock()
+		contended <- waited
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	held.Unlock()
+	require.True(t, <-contended)
+
+	lock, waited := pool.Lock(5)
+	require.False(t, waited)
+	lock.Unlock()
+
+	hot := pool.Hot(10)
+	require.Len(t, hot, 1)
+	require.Equal(t, 3, hot[0].Stripe)
+	require.Equal(t, uint64(1), hot[0].Contended)
+	require.Equal(t, []uint64{19}, hot[0].Hashes)
+	require.GreaterOrEqual(t, hot[0].Wait, 5*time.Millisecond)
+	require.Len(t, pool.Stripes(), 16)
+	require.InDelta(t, 0.5, pool.Contention(), 0.001)
+}
+
+func Test_PoolSize(t *testing.T) {
+	require.Equal(t, 32, stats.PoolSize(8, 0.25))
+	require.Equal(t, 80, stats.PoolSize(8, 0.1))
+	require.Equal(t, 10, stats.PoolSize(1, 0.5))
+}
@@ -0,0 +1,50 @@
+package stats
+
+// Stats is a snapshot of the statistics of a collection
+type Stats struct {
+	Hits             uint64            // Lookups that found their key
+	Misses           uint64            // Lookups that did not find their key
+	NegativeHits     uint64            // Lookups that found their key known to be absent, through a negative entry
+	Loads            uint64            // Calls to the loader, including failed ones
+	LoadErrors       uint64            // Calls to the loader that failed
+	Evictions        uint64            // Entries removed to stay within the weight budget
+	Expirations      uint64            // Entries found past their expiry
+	Contention       uint64            // Item locks that were held by another goroutine when needed
+	LoadLatency      HistogramSnapshot // How long the calls to the loader took
+	BucketContention []uint64          // The contention per bucket of the collection
+}
+
+// Source is a collection that reports its statistics
+type Source interface {
+	Stats() Stats
+}
+
+// Add returns the sum of both snapshots, the buckets of other follow the buckets of s
+func (s Stats) Add(other Stats) Stats {
+	buckets := make([]uint64, 0, len(s.BucketContention)+len(other.BucketContention))
+	buckets = append(buckets, s.BucketContention...)
+	buckets = append(buckets, other.BucketContention...)
+
+	return Stats{
+		Hits:             s.Hits + other.Hits,
+		Misses:           s.Misses + other.Misses,
+		NegativeHits:     s.NegativeHits + other.NegativeHits,
+		Loads:            s.Loads + other.Loads,
+		LoadErrors:       s.LoadErrors + other.LoadErrors,
+		Evictions:        s.Evictions + other.Evictions,
+		Expirations:      s.Expirations + other.Expirations,
+		Contention:       s.Contention + other.Contention,
+		LoadLatency:      s.LoadLatency.Add(other.LoadLatency),
+		BucketContention: buckets,
+	}
+}
+
+// HitRatio returns the share of lookups that found a value for their key, 0 without any lookups
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses + s.NegativeHits
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(total)
+}
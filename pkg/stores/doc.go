@@ -0,0 +1,2 @@
+// stores provides backing stores that collections can persist their items to.
+package stores
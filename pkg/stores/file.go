@@ -0,0 +1,265 @@
+package stores
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+var _ Store[string, string] = &File[string, string]{}
+
+// File is a [Store] that appends every change as a json line to a log file.
+// Only the offsets of the latest records are kept in memory, values are read back from the file on load.
+// Keys and values need to be json serializable.
+type File[K comparable, V any] struct {
+	path    string
+	file    *os.File
+	offsets map[K]span
+	size    int64
+	lock    sync.RWMutex
+}
+
+type span struct {
+	offset int64
+	length int64
+}
+
+type record[K comparable, V any] struct {
+	Key     K    `json:"k"`
+	Value   V    `json:"v"`
+	Deleted bool `json:"d,omitempty"`
+}
+
+// OpenFile opens or creates the log file at the given path, and replays it to rebuild the offsets.
+// A partially written record at the end of the file, from a crash, is discarded, corrupt records before it are skipped.
+func OpenFile[K comparable, V any](path string) (*File[K, V], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File[K, V]{
+		path:    path,
+		file:    file,
+		offsets: make(map[K]span),
+		size:    0,
+		lock:    sync.RWMutex{},
+	}
+
+	if err := f.replay(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File[K, V]) replay() error {
+	reader := bufio.NewReader(f.file)
+	offset := int64(0)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Records always end with a newline, anything after the last one is torn
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		length := int64(len(line))
+		var r record[K, V]
+		if err := json.Unmarshal(line, &r); err != nil {
+			offset += length
+			continue
+		}
+
+		if r.Deleted {
+			delete(f.offsets, r.Key)
+		} else {
+			f.offsets[r.Key] = span{offset, length}
+		}
+		offset += length
+	}
+
+	// Drop the torn record after the last complete one
+	f.size = offset
+	return f.file.Truncate(offset)
+}
+
+// Load implements Store.
+func (f *File[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	var empty V
+	if err := ctx.Err(); err != nil {
+		return empty, false, err
+	}
+
+	s, ok := f.offsets[key]
+	if !ok {
+		return empty, false, nil
+	}
+
+	r, err := f.read(s)
+	if err != nil {
+		return empty, false, err
+	}
+
+	return r.Value, true, nil
+}
+
+// Store implements Store.
+func (f *File[K, V]) Store(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f.append(record[K, V]{Key: key, Value: value})
+}
+
+// Delete implements Store.
+func (f *File[K, V]) Delete(ctx context.Context, key K) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.lock.RLock()
+	_, ok := f.offsets[key]
+	f.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return f.append(record[K, V]{Key: key, Deleted: true})
+}
+
+// LoadBatch implements Store.
+func (f *File[K, V]) LoadBatch(ctx context.Context, keys []K) (map[K]V, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		s, ok := f.offsets[key]
+		if !ok {
+			continue
+		}
+
+		r, err := f.read(s)
+		if err != nil {
+			return result, err
+		}
+		result[key] = r.Value
+	}
+
+	return result, nil
+}
+
+// Len returns the amount of keys in the store.
+func (f *File[K, V]) Len() int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return len(f.offsets)
+}
+
+// Sync commits the written records to stable storage.
+func (f *File[K, V]) Sync() error {
+	return f.file.Sync()
+}
+
+// Close syncs and closes the underlying file.
+func (f *File[K, V]) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return errors.Join(f.file.Sync(), f.file.Close())
+}
+
+// Compact rewrites the log with only the latest record of every key, dropping overwritten and deleted records.
+func (f *File[K, V]) Compact() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	tmp := f.path + ".compact"
+	out, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[K]span, len(f.offsets))
+	size := int64(0)
+
+	for key, s := range f.offsets {
+		buf := make([]byte, s.length)
+		if _, err := f.file.ReadAt(buf, s.offset); err != nil {
+			return errors.Join(err, out.Close(), os.Remove(tmp))
+		}
+		if _, err := out.Write(buf); err != nil {
+			return errors.Join(err, out.Close(), os.Remove(tmp))
+		}
+
+		offsets[key] = span{size, s.length}
+		size += s.length
+	}
+
+	if err := out.Sync(); err != nil {
+		return errors.Join(err, out.Close(), os.Remove(tmp))
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return errors.Join(err, out.Close(), os.Remove(tmp))
+	}
+
+	_ = f.file.Close()
+	f.file = out
+	f.offsets = offsets
+	f.size = size
+	return nil
+}
+
+func (f *File[K, V]) read(s span) (record[K, V], error) {
+	var r record[K, V]
+	buf := make([]byte, s.length)
+	if _, err := f.file.ReadAt(buf, s.offset); err != nil {
+		return r, err
+	}
+
+	err := json.Unmarshal(bytes.TrimSpace(buf), &r)
+	return r, err
+}
+
+func (f *File[K, V]) append(r record[K, V]) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, err := f.file.WriteAt(data, f.size); err != nil {
+		return err
+	}
+
+	length := int64(len(data))
+	if r.Deleted {
+		delete(f.offsets, r.Key)
+	} else {
+		f.offsets[r.Key] = span{f.size, length}
+	}
+	f.size += length
+	return nil
+}
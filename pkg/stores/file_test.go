@@ -0,0 +1,119 @@
+package stores_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daanv2/go-cache/pkg/stores"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_File_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	store, err := stores.OpenFile[int, string](path)
+	require.NoError(t, err)
+
+	for i := range 100 {
+		require.NoError(t, store.Store(ctx, i, "first"))
+	}
+	for i := range 50 {
+		require.NoError(t, store.Store(ctx, i, "second"))
+	}
+	for i := range 10 {
+		require.NoError(t, store.Delete(ctx, i))
+	}
+	require.NoError(t, store.Close())
+
+	// Simulate a crash halfway through a write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"k":1000,"v":"to`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = stores.OpenFile[int, string](path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.Equal(t, 90, store.Len())
+
+	_, ok, err := store.Load(ctx, 5)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	v, ok, err := store.Load(ctx, 20)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "second", v)
+
+	v, ok, err = store.Load(ctx, 70)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "first", v)
+
+	_, ok, err = store.Load(ctx, 1000)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func Test_File_Compact(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	store, err := stores.OpenFile[int, string](path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	for range 10 {
+		for i := range 20 {
+			require.NoError(t, store.Store(ctx, i, "value"))
+		}
+	}
+
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Compact())
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, after.Size(), before.Size())
+
+	values, err := store.LoadBatch(ctx, []int{1, 2, 3, 100})
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+
+	// Writes after compacting still land
+	require.NoError(t, store.Store(ctx, 100, "new"))
+	v, ok, err := store.Load(ctx, 100)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "new", v)
+}
+
+func Test_File_CorruptRecord(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	log := `{"k":1,"v":"one"}` + "\n" + `{"k":2,"v":` + "\n" + `{"k":3,"v":"three"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0o644))
+
+	store, err := stores.OpenFile[int, string](path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// The records after the corrupt one are kept
+	require.Equal(t, 2, store.Len())
+	v, ok, err := store.Load(ctx, 3)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "three", v)
+
+	// New records are appended after them
+	require.NoError(t, store.Store(ctx, 4, "four"))
+	v, _, err = store.Load(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, "four", v)
+}
@@ -0,0 +1,80 @@
+package stores
+
+import (
+	"context"
+	"sync"
+)
+
+var _ Store[string, string] = &Memory[string, string]{}
+
+// Memory is a [Store] that keeps everything in memory, useful for tests or as a reference implementation.
+type Memory[K comparable, V any] struct {
+	items map[K]V
+	lock  sync.RWMutex
+}
+
+// NewMemory creates a new empty in memory store.
+func NewMemory[K comparable, V any]() *Memory[K, V] {
+	return &Memory[K, V]{
+		items: make(map[K]V),
+		lock:  sync.RWMutex{},
+	}
+}
+
+// Load implements Store.
+func (m *Memory[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	v, ok := m.items[key]
+	return v, ok, ctx.Err()
+}
+
+// Store implements Store.
+func (m *Memory[K, V]) Store(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.items[key] = value
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory[K, V]) Delete(ctx context.Context, key K) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.items, key)
+	return nil
+}
+
+// LoadBatch implements Store.
+func (m *Memory[K, V]) LoadBatch(ctx context.Context, keys []K) (map[K]V, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if v, ok := m.items[key]; ok {
+			result[key] = v
+		}
+	}
+
+	return result, ctx.Err()
+}
+
+// Len returns the amount of items in the store.
+func (m *Memory[K, V]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.items)
+}
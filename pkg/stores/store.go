@@ -0,0 +1,15 @@
+package stores
+
+import "context"
+
+// Store is a backing store that items can be persisted to and loaded from.
+type Store[K comparable, V any] interface {
+	// Load returns the value for the key, and false if the store does not hold it.
+	Load(ctx context.Context, key K) (V, bool, error)
+	// Store persists the value for the key.
+	Store(ctx context.Context, key K, value V) error
+	// Delete removes the key from the store, removing a missing key is not an error.
+	Delete(ctx context.Context, key K) error
+	// LoadBatch returns the values for all the keys that the store holds, missing keys are left out.
+	LoadBatch(ctx context.Context, keys []K) (map[K]V, error)
+}
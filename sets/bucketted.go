@@ -9,6 +9,7 @@ import (
 	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-cache/pkg/iterators"
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-kit/generics"
 )
 
@@ -76,6 +77,21 @@ func (s *BuckettedSet[T]) bucketIndex(item SetItem[T]) uint64 {
 	return item.Hash % uint64(len(s.sets))
 }
 
+// Stats returns the statistics of the set summed over its buckets, all zero unless created with [WithStats]
+func (s *BuckettedSet[T]) Stats() stats.Stats {
+	total := stats.Stats{}
+	contention := make([]uint64, 0, len(s.sets))
+	for _, bucket := range s.sets {
+		b := bucket.Stats()
+		contention = append(contention, b.Contention)
+		b.BucketContention = nil
+		total = total.Add(b)
+	}
+	total.BucketContention = contention
+
+	return total
+}
+
 // Read will return a sequence of all items in the set
 func (s *BuckettedSet[T]) Read() iter.Seq[T] {
 	return func(yield func(T) bool) {
@@ -0,0 +1,69 @@
+package sets
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/constraints"
+	"github.com/daanv2/go-cache/pkg/hash"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// EqualSet is a set for items that can not be compared with ==, such as slices or case insensitive strings.
+// Items are matched by an equality function, which has to agree with the hasher: equal items need equal hashes.
+// It keeps the items as the keys of a [maps.EqualMap].
+type EqualSet[T any] struct {
+	data *maps.EqualMap[T, struct{}]
+}
+
+// NewEqualSet creates a new EqualSet with the specified capacity, hasher, equality function and the options of the underlying map.
+func NewEqualSet[T any](capacity uint64, hasher hash.Hasher[T], equal func(a, b T) bool, opts ...options.Option[maps.Options]) (*EqualSet[T], error) {
+	data, err := maps.NewEqualMap[T, struct{}](capacity, hasher, equal, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EqualSet[T]{data}, nil
+}
+
+// NewEquivalentSet creates a new EqualSet for items that compare themselves, see [constraints.Equivalent]
+func NewEquivalentSet[T constraints.Equivalent[T]](capacity uint64, hasher hash.Hasher[T], opts ...options.Option[maps.Options]) (*EqualSet[T], error) {
+	return NewEqualSet(capacity, hasher, func(a, b T) bool { return a.Equal(b) }, opts...)
+}
+
+// Has returns true if an equal item is in the set
+func (s *EqualSet[T]) Has(item T) bool {
+	_, ok := s.data.Get(item)
+	return ok
+}
+
+// UpdateOrAdd will replace an equal item if it exists, otherwise it will add the item to the set, and return true if it had to add it
+func (s *EqualSet[T]) UpdateOrAdd(item T) bool {
+	return s.data.Replace(item, struct{}{})
+}
+
+// Add adds the item unless an equal item is in the set, and returns true if it was added
+func (s *EqualSet[T]) Add(item T) bool {
+	return s.data.Add(item, struct{}{})
+}
+
+// Delete removes the equal item from the set, and returns true if it was found
+func (s *EqualSet[T]) Delete(item T) bool {
+	_, ok := s.data.Delete(item)
+	return ok
+}
+
+// Read will return a sequence of all items in the set
+func (s *EqualSet[T]) Read() iter.Seq[T] {
+	return s.data.Keys()
+}
+
+func (s *EqualSet[T]) String() string {
+	return fmt.Sprintf("sets.EqualSet[%s]", generics.NameOf[T]())
+}
+
+func (s *EqualSet[T]) GoString() string {
+	return s.String()
+}
@@ -4,40 +4,81 @@ import (
 	"iter"
 	"sync"
 
+	"github.com/daanv2/go-cache/pkg/arena"
 	"github.com/daanv2/go-cache/pkg/bloomfilters"
+	"github.com/daanv2/go-cache/pkg/probing"
 )
 
-// Fixed is a fixed size slice, that can be used to store a fixed amount of items
+// Fixed is a fixed size slice, that can be used to store a fixed amount of items.
+// Slots are probed in groups of 8 through SwissTable style control bytes, see [probing], so lookups for missing items stop early.
+// When created for a pointer free set, see [WithPointerFree], the slots hold handles of items copied into an arena instead.
 type Fixed[T comparable] struct {
-	amount    uint64
 	hashrange *bloomfilters.Cheap
+	ctrl      []uint64     // The control bytes of the slots, grouped per 8
 	items     []SetItem[T] // The items in the slice
+	packer    *packer[T]   // Set for pointer free storage, items is unused then
+	handles   []uint64     // The handles of the items of pointer free storage, 0 is an empty slot
+	arena     *arena.Arena // The arena the handles point into
 	lock      sync.RWMutex // The lock to protect the slice
 }
 
 func NewFixed[T comparable](amount uint64) Fixed[T] {
 	return Fixed[T]{
-		amount:    amount,
+		ctrl:      probing.NewControl(amount),
 		items:     make([]SetItem[T], amount),
 		hashrange: bloomfilters.NewCheap(amount),
 		lock:      sync.RWMutex{},
 	}
 }
 
+// newPackedFixed creates a Fixed that stores its items in an arena through the packer
+func newPackedFixed[T comparable](amount uint64, p *packer[T]) Fixed[T] {
+	return Fixed[T]{
+		ctrl:      probing.NewControl(amount),
+		packer:    p,
+		handles:   make([]uint64, amount),
+		arena:     arena.New(int(min(amount*(packed_header_size+32), 64*1024))),
+		hashrange: bloomfilters.NewCheap(amount),
+		lock:      sync.RWMutex{},
+	}
+}
+
 func (s *Fixed[T]) Cap() int {
-	return cap(s.items)
+	return s.Len()
 }
 
 func (s *Fixed[T]) Len() int {
+	if s.packer != nil {
+		return len(s.handles)
+	}
+
 	return len(s.items)
 }
 
-func (s *Fixed[T]) HasHash(hash uint64) bool {
-	return s.hashrange.Has(hash)
+// load returns the item in the slot, the caller is expected to hold the lock
+func (s *Fixed[T]) load(i uint64) SetItem[T] {
+	if s.packer == nil {
+		return s.items[i]
+	}
+	if s.handles[i] == 0 {
+		return SetItem[T]{}
+	}
+
+	return s.packer.read(s.arena, arena.Handle(s.handles[i]))
+}
+
+// store places the item in an empty slot, the caller is expected to hold the lock
+func (s *Fixed[T]) store(i uint64, item SetItem[T]) {
+	if s.packer == nil {
+		s.items[i] = item
+		return
+	}
+
+	s.handles[i] = uint64(s.packer.write(s.arena, item))
 }
 
-func (s *Fixed[T]) index(item SetItem[T]) uint64 {
-	return item.Hash % s.amount
+func (s *Fixed[T]) HasHash(hash uint64) bool {
+	return s.hashrange.Has(hash)
 }
 
 func (s *Fixed[T]) Get(item SetItem[T]) (SetItem[T], bool) {
@@ -52,23 +93,41 @@ func (s *Fixed[T]) Get(item SetItem[T]) (SetItem[T], bool) {
 }
 
 func (s *Fixed[T]) get(item SetItem[T]) (SetItem[T], bool) {
-	sindex := s.index(item)
+	i := s.find(item)
+	if i < 0 {
+		return item, false
+	}
 
-	sub := s.items[sindex:]
-	for _, v := range sub {
-		if sameItem(item, v) {
-			return v, true
+	return s.load(uint64(i)), true
+}
+
+// find returns the slot index of the same item, or -1 if it is not present
+func (s *Fixed[T]) find(item SetItem[T]) int {
+	h2 := probing.H2(item.Hash)
+	groups := uint64(len(s.ctrl))
+	g := probing.Start(item.Hash, groups)
+
+	for range groups {
+		word := s.ctrl[g]
+		for m := probing.MatchH2(word, h2); m.Any(); m = m.Next() {
+			i := g*probing.GroupSize + m.First()
+			if sameItem(item, s.load(i)) {
+				return int(i)
+			}
+		}
+
+		// The item would have been placed in this group if it existed
+		if probing.MatchEmpty(word).Any() {
+			return -1
 		}
-	}
 
-	sub = s.items[:sindex]
-	for _, v := range sub {
-		if sameItem(item, v) {
-			return v, true
+		g++
+		if g == groups {
+			g = 0
 		}
 	}
 
-	return item, false
+	return -1
 }
 
 // Set Add the given item to the set, if equivalant item was overriden, or empty space filled, true is returned
@@ -80,24 +139,31 @@ func (s *Fixed[T]) Set(item SetItem[T]) bool {
 }
 
 func (s *Fixed[T]) set(item SetItem[T]) bool {
-	sindex := s.index(item)
-
-	sub := s.items[sindex:]
-	for i, v := range sub {
-		if sameItem(item, v) || v.IsEmpty() {
-			sub[i] = item
-			s.hashrange.Set(item.Hash)
-			return true
+	if i := s.find(item); i >= 0 {
+		// The item is equal to the one stored, pointer free storage keeps the stored copy
+		if s.packer == nil {
+			s.items[i] = item
 		}
+		return true
 	}
 
-	sub = s.items[:sindex]
-	for i, v := range sub {
-		if sameItem(item, v) || v.IsEmpty() {
-			sub[i] = item
+	groups := uint64(len(s.ctrl))
+	g := probing.Start(item.Hash, groups)
+
+	for range groups {
+		word := s.ctrl[g]
+		if m := probing.MatchFree(word); m.Any() {
+			slot := m.First()
+			s.store(g*probing.GroupSize+slot, item)
+			s.ctrl[g] = probing.Set(word, slot, probing.H2(item.Hash))
 			s.hashrange.Set(item.Hash)
 			return true
 		}
+
+		g++
+		if g == groups {
+			g = 0
+		}
 	}
 
 	return false
@@ -111,24 +177,15 @@ func (s *Fixed[T]) Update(item SetItem[T]) bool {
 }
 
 func (s *Fixed[T]) update(item SetItem[T]) bool {
-	sindex := s.index(item)
-	sub := s.items[sindex:]
-	for i, v := range sub {
-		if sameItem(item, v) {
-			sub[i] = item
-			return true
-		}
+	i := s.find(item)
+	if i < 0 {
+		return false
 	}
 
-	sub = s.items[:sindex]
-	for i, v := range sub {
-		if sameItem(item, v) {
-			sub[i] = item
-			return true
-		}
+	if s.packer == nil {
+		s.items[i] = item
 	}
-
-	return false
+	return true
 }
 
 func (s *Fixed[T]) Read() iter.Seq[SetItem[T]] {
@@ -136,7 +193,8 @@ func (s *Fixed[T]) Read() iter.Seq[SetItem[T]] {
 		s.lock.RLock()
 		defer s.lock.RUnlock()
 
-		for _, v := range s.items {
+		for i := range uint64(s.Len()) {
+			v := s.load(i)
 			if v.IsEmpty() {
 				continue
 			}
@@ -1,8 +1,14 @@
 package sets_test
 
 import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-cache/sets"
 	"github.com/stretchr/testify/require"
 )
@@ -40,3 +46,109 @@ func Test_Set(t *testing.T) {
 		check[item.Value] = true
 	}
 }
+
+func Test_BuckettedSet_PointerFree(t *testing.T) {
+	col, err := sets.NewBuckettedSet[string](1000, stringHasher{}, sets.WithPointerFree())
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		v, ok := col.GetOrAdd(fmt.Sprint(i))
+		require.True(t, ok)
+		require.Equal(t, fmt.Sprint(i), v)
+	}
+	for i := range 1000 {
+		v, _ := col.GetOrAdd(fmt.Sprint(i))
+		require.Equal(t, fmt.Sprint(i), v)
+	}
+
+	count := 0
+	for range col.Read() {
+		count++
+	}
+	require.Equal(t, 1000, count)
+
+	_, err = sets.NewBuckettedSet[*int](1000, pointerHasher{}, sets.WithPointerFree())
+	require.Error(t, err)
+}
+
+type stringHasher struct{}
+
+func (stringHasher) Hash(item string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+	return h.Sum64()
+}
+
+type pointerHasher struct{}
+
+func (pointerHasher) Hash(item *int) uint64 { return 0 }
+
+func Test_BuckettedSet_Stats(t *testing.T) {
+	col, err := sets.NewBuckettedSet[string](100, stringHasher{}, sets.WithBucketAmount(2), sets.WithBucketSize(16), sets.WithStats())
+	require.NoError(t, err)
+
+	for i := range 100 {
+		col.GetOrAdd(fmt.Sprint(i))
+	}
+
+	s := col.Stats()
+	require.Equal(t, uint64(100), s.Misses)
+	require.Len(t, s.BucketContention, 2)
+}
+
+func Test_EqualSet(t *testing.T) {
+	hasher := hash.NewFunctionHasher(hash.Sha1, func(item string) []byte {
+		return []byte(strings.ToLower(item))
+	})
+	s, err := sets.NewEqualSet(10, hasher, strings.EqualFold)
+	require.NoError(t, err)
+
+	require.True(t, s.Add("Hello"))
+	require.False(t, s.Add("hello"))
+	require.True(t, s.Has("HELLO"))
+	require.Equal(t, []string{"Hello"}, slices.Collect(s.Read()))
+
+	require.False(t, s.UpdateOrAdd("hello"))
+	require.Equal(t, []string{"hello"}, slices.Collect(s.Read()))
+
+	require.True(t, s.Delete("HeLLo"))
+	require.False(t, s.Has("hello"))
+	require.False(t, s.Delete("hello"))
+}
+
+func Test_EqualSet_Concurrent(t *testing.T) {
+	hasher := hash.NewFunctionHasher(hash.Sha1, func(item string) []byte {
+		return []byte(strings.ToLower(item))
+	})
+	s, err := sets.NewEqualSet(10, hasher, strings.EqualFold)
+	require.NoError(t, err)
+
+	// Only one of the callers adding an item, or an equal one, sees it added
+	added := make(chan bool, 8*100*2)
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				item := fmt.Sprint("item", i)
+				if w%2 == 0 {
+					item = strings.ToUpper(item)
+				}
+				added <- s.Add(item)
+				added <- s.UpdateOrAdd(item)
+			}
+		}()
+	}
+	wg.Wait()
+	close(added)
+
+	count := 0
+	for ok := range added {
+		if ok {
+			count++
+		}
+	}
+	require.Equal(t, 100, count)
+	require.Len(t, slices.Collect(s.Read()), 100)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-cache/pkg/iterators"
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-kit/generics"
 	optimal "github.com/daanv2/go-optimal"
 )
@@ -19,6 +20,8 @@ type GrowableSet[T comparable] struct {
 	hasher      hash.Hasher[T]
 	buckets     []*Fixed[T]
 	bucket_lock sync.RWMutex
+	packer      *packer[T]      // Set when the items are stored without pointers
+	stats       *stats.Counters // Set when stats are recorded, see [WithStats]
 }
 
 // NewGrowableSet creates a new instance of GrowableSet with the provided hasher and options.
@@ -46,11 +49,26 @@ func NewGrowableSetFrom[T comparable](hasher hash.Hasher[T], base Options) (*Gro
 		return nil, errors.New("bucket size is too small <= 1")
 	}
 
+	var p *packer[T]
+	if base.pointer_free {
+		var err error
+		if p, err = newPacker[T](); err != nil {
+			return nil, err
+		}
+	}
+
+	var counters *stats.Counters
+	if base.stats {
+		counters = stats.NewCounters()
+	}
+
 	return &GrowableSet[T]{
 		Options:     base,
 		hasher:      hasher,
 		buckets:     make([]*Fixed[T], 0),
 		bucket_lock: sync.RWMutex{},
+		packer:      p,
+		stats:       counters,
 	}, nil
 }
 
@@ -69,18 +87,18 @@ func (s *GrowableSet[T]) UpdateOrAdd(item T) bool {
 }
 
 func (s *GrowableSet[T]) getOrAdd(item SetItem[T]) (T, bool) {
-	item_lock := s.items_lock.GetLock(item.Hash)
-
-	item_lock.Lock()
+	item_lock := s.lockItem(item.Hash)
 	defer item_lock.Unlock()
 
 	l := len(s.buckets)
 	switch l {
 	case 0:
+		s.stats.Miss()
 		s.set(item)
 		return item.Value, true
 	case 1:
 		if s.buckets[0].Set(item) {
+			s.stats.Miss()
 			return item.Value, true
 		}
 
@@ -88,19 +106,39 @@ func (s *GrowableSet[T]) getOrAdd(item SetItem[T]) (T, bool) {
 		// Find it
 		v, ok := s.Find(item)
 		if ok {
+			s.stats.Hit()
 			return v.Value, false
 		}
 	}
 
+	s.stats.Miss()
 	s.set(item)
 	return item.Value, true
 }
 
+// lockItem locks the item lock of the hash, recording if it had to wait for it
+func (s *GrowableSet[T]) lockItem(hash uint64) *sync.Mutex {
+	if s.items_lock_stats != nil {
+		item_lock, waited := s.items_lock_stats.Lock(hash)
+		if waited {
+			s.stats.Contended()
+		}
+
+		return item_lock
+	}
+
+	item_lock := s.items_lock.GetLock(hash)
+	if !item_lock.TryLock() {
+		s.stats.Contended()
+		item_lock.Lock()
+	}
+
+	return item_lock
+}
+
 // updateOrAdd TODO. return true if it had to add it instead of update
 func (s *GrowableSet[T]) updateOrAdd(item SetItem[T]) bool {
-	item_lock := s.items_lock.GetLock(item.Hash)
-
-	item_lock.Lock()
+	item_lock := s.lockItem(item.Hash)
 	defer item_lock.Unlock()
 
 	// Find it
@@ -152,7 +190,7 @@ func (s *GrowableSet[T]) set(item SetItem[T]) {
 	}
 
 	for {
-		b := NewFixed[T](s.Options.bucket_size)
+		b := s.newBucket()
 		s.buckets = append(s.buckets, &b)
 		if s.buckets[len(s.buckets)-1].Set(item) {
 			return
@@ -160,6 +198,15 @@ func (s *GrowableSet[T]) set(item SetItem[T]) {
 	}
 }
 
+// newBucket creates a bucket, storing its items without pointers if the set does
+func (s *GrowableSet[T]) newBucket() Fixed[T] {
+	if s.packer != nil {
+		return newPackedFixed(s.Options.bucket_size, s.packer)
+	}
+
+	return NewFixed[T](s.Options.bucket_size)
+}
+
 func (s *GrowableSet[T]) Find(item SetItem[T]) (SetItem[T], bool) {
 	s.bucket_lock.RLock()
 	defer s.bucket_lock.RUnlock()
@@ -179,6 +226,11 @@ func (s *GrowableSet[T]) Find(item SetItem[T]) (SetItem[T], bool) {
 	return item, false
 }
 
+// Stats returns the statistics of the set, all zero unless created with [WithStats]
+func (s *GrowableSet[T]) Stats() stats.Stats {
+	return s.stats.Stats()
+}
+
 // Read returns an iterator that reads the items in the set.
 func (s *GrowableSet[T]) Read() iter.Seq[T] {
 	return func(yield func(T) bool) {
@@ -2,6 +2,7 @@ package sets
 
 import (
 	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/pkg/stats"
 	"github.com/daanv2/go-locks"
 	optimal "github.com/daanv2/go-optimal"
 	"github.com/daanv2/go-optimal/pkg/cpu"
@@ -13,6 +14,9 @@ type Options struct {
 	items_lock       *locks.Pool
 	bucket_amount    uint64
 	bucket_amount_fn func(uint64) uint64
+	pointer_free     bool
+	stats            bool
+	items_lock_stats *stats.InstrumentedPool // Set when the item locks are instrumented, wraps items_lock
 }
 
 // CreateOptions creates a new instance of SetBase with the default bucket size.
@@ -22,6 +26,9 @@ func CreateOptions[T any](opts ...options.Option[Options]) (Options, error) {
 		items_lock:  locks.NewPool(),
 		bucket_amount: 0,
 		bucket_amount_fn: nil,
+		pointer_free:     false,
+		stats:            false,
+		items_lock_stats: nil,
 	}
 
 	err := options.Apply(&op, opts...)
@@ -57,6 +64,23 @@ func WithMaxBucketSize(size uint64) options.Option[Options] {
 func WithItemLocks(pool *locks.Pool) options.Option[Options] {
 	return options.NewFunction(func(option *Options) {
 		option.items_lock = pool
+		option.items_lock_stats = nil
+	})
+}
+
+// WithInstrumentedItemLocks sets the locks that the set will use, recording how long locking them waits, see [stats.InstrumentedPool.Hot]
+func WithInstrumentedItemLocks(pool *stats.InstrumentedPool) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.items_lock = pool.Pool()
+		option.items_lock_stats = pool
+	})
+}
+
+// WithConcurrency sizes the item locks for the amount of goroutines that write concurrently, see [stats.PoolSize]
+func WithConcurrency(concurrency int) options.Option[Options] {
+	return options.NewFunction(func(option *Options) {
+		option.items_lock = locks.NewPool(locks.WithSize(stats.PoolSize(concurrency, 0.25)))
+		option.items_lock_stats = nil
 	})
 }
 
@@ -80,3 +104,18 @@ func WithBucketFunction(calc func(capacity uint64) uint64) options.Option[Option
 		option.bucket_amount_fn = calc
 	})
 }
+
+// WithPointerFree stores the items copied into byte arenas, referenced by offset, so the buckets hold no pointers for the garbage collector to scan.
+// Items have to be strings or types without pointers, strings read from the set reference the arenas
+func WithPointerFree() options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.pointer_free = true
+	})
+}
+
+// WithStats records hits, misses and lock contention per bucket, reported by [BuckettedSet.Stats]
+func WithStats() options.Option[Options] {
+	return options.NewFunction[Options](func(option *Options) {
+		option.stats = true
+	})
+}
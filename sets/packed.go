@@ -0,0 +1,47 @@
+package sets
+
+import (
+	"encoding/binary"
+
+	"github.com/daanv2/go-cache/pkg/arena"
+)
+
+// packed_header_size is the size of the header in front of every packed item: the hash and the value length
+const packed_header_size = 12
+
+// packer copies items into arenas, for sets storing their items without pointers, see [WithPointerFree]
+type packer[T comparable] struct {
+	values arena.Codec[T]
+}
+
+func newPacker[T comparable]() (*packer[T], error) {
+	values, err := arena.NewCodec[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return &packer[T]{values}, nil
+}
+
+// write copies the item into the arena, returning its handle
+func (p *packer[T]) write(a *arena.Arena, item SetItem[T]) arena.Handle {
+	vlen := p.values.Len(item.Value)
+	h, data := a.Alloc(packed_header_size + vlen)
+
+	binary.LittleEndian.PutUint64(data, item.Hash)
+	binary.LittleEndian.PutUint32(data[8:], uint32(vlen))
+	p.values.Put(data[packed_header_size:], item.Value)
+
+	return h
+}
+
+// read returns the item the handle points at, strings reference the arena
+func (p *packer[T]) read(a *arena.Arena, h arena.Handle) SetItem[T] {
+	data := a.Bytes(h)
+	vlen := int(binary.LittleEndian.Uint32(data[8:]))
+
+	return SetItem[T]{
+		Hash:  binary.LittleEndian.Uint64(data),
+		Value: p.values.Get(data[packed_header_size : packed_header_size+vlen]),
+	}
+}
@@ -0,0 +1,130 @@
+package slices
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"sync/atomic"
+
+	"github.com/daanv2/go-kit/generics"
+)
+
+// Chunked is an append only slice that grows by adding [Fixed] chunks, existing items are never copied or moved.
+//
+// The index returned by [Chunked.Append] stays valid for as long as the slice lives, which makes it usable as a reference, for example from a map.
+// The chain of chunks is replaced as a whole when it grows, like the buckets of a maps.GrowableMap, so reads never take the chunked lock.
+type Chunked[T any] struct {
+	chunks     atomic.Pointer[[]*Fixed[T]] // Replaced as a whole when a chunk is added
+	chunk_size int
+	grow_lock  sync.Mutex // Serializes adding chunks
+}
+
+// NewChunked creates a new Chunked slice that grows in chunks of chunk_size items
+func NewChunked[T any](chunk_size int) (*Chunked[T], error) {
+	if chunk_size <= 0 {
+		return nil, errors.New("chunk size has to be larger than 0")
+	}
+
+	c := &Chunked[T]{
+		chunk_size: chunk_size,
+	}
+	c.chunks.Store(&[]*Fixed[T]{})
+
+	return c, nil
+}
+
+// Append adds the item to the end of the slice, returning its index. Appenders do not block each other, unless a chunk has to be added
+func (c *Chunked[T]) Append(item T) int {
+	for {
+		chunks := *c.chunks.Load()
+		if l := len(chunks); l > 0 {
+			if i, ok := chunks[l-1].Append(item); ok {
+				return (l-1)*c.chunk_size + i
+			}
+		}
+
+		c.grow(len(chunks))
+	}
+}
+
+// grow adds a chunk, unless another appender already did since the chain had seen chunks
+func (c *Chunked[T]) grow(seen int) {
+	c.grow_lock.Lock()
+	defer c.grow_lock.Unlock()
+
+	chunks := *c.chunks.Load()
+	if len(chunks) != seen {
+		return
+	}
+
+	chunk := NewFixed[T](c.chunk_size)
+	// Copy the chain, readers might still be walking the old one
+	chunks = append(chunks[:len(chunks):len(chunks)], &chunk)
+	c.chunks.Store(&chunks)
+}
+
+// Get returns the item at the index, and false if no item was appended at it
+func (c *Chunked[T]) Get(index int) (T, bool) {
+	if index < 0 {
+		return generics.Empty[T](), false
+	}
+
+	chunks := *c.chunks.Load()
+	chunk := index / c.chunk_size
+	if chunk >= len(chunks) {
+		return generics.Empty[T](), false
+	}
+
+	return chunks[chunk].Get(index % c.chunk_size)
+}
+
+// Len returns the amount of items that can be read, which may already be outdated when it returns
+func (c *Chunked[T]) Len() int {
+	total := 0
+	for _, chunk := range *c.chunks.Load() {
+		total += chunk.Len()
+	}
+
+	return total
+}
+
+// Chunks returns the amount of chunks in the slice
+func (c *Chunked[T]) Chunks() int {
+	return len(*c.chunks.Load())
+}
+
+// All returns a sequence of the indexes and items in the slice, in the order they were appended.
+// Items appended while iterating may or may not be seen
+func (c *Chunked[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for n, chunk := range *c.chunks.Load() {
+			i := 0
+			for item := range chunk.Read() {
+				if !yield(n*c.chunk_size+i, item) {
+					return
+				}
+				i++
+			}
+		}
+	}
+}
+
+// Read will return a sequence of the items in the slice, in the order they were appended
+func (c *Chunked[T]) Read() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range c.All() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Chunked[T]) String() string {
+	return fmt.Sprintf("slices.Chunked[%s,%d]", generics.NameOf[T](), c.Chunks())
+}
+
+func (c *Chunked[T]) GoString() string {
+	return c.String()
+}
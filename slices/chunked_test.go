@@ -0,0 +1,74 @@
+package slices_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/slices"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Chunked(t *testing.T) {
+	col, err := slices.NewChunked[int](4)
+	require.NoError(t, err)
+
+	for i := range 10 {
+		require.Equal(t, i, col.Append(i*10))
+	}
+	require.Equal(t, 3, col.Chunks())
+	require.Equal(t, 10, col.Len())
+
+	v, ok := col.Get(9)
+	require.True(t, ok)
+	require.Equal(t, 90, v)
+	_, ok = col.Get(10)
+	require.False(t, ok)
+	_, ok = col.Get(-1)
+	require.False(t, ok)
+
+	for i, v := range col.All() {
+		require.Equal(t, i*10, v)
+	}
+
+	_, err = slices.NewChunked[int](0)
+	require.Error(t, err)
+}
+
+func Test_Chunked_Parralel(t *testing.T) {
+	col, err := slices.NewChunked[int](16)
+	require.NoError(t, err)
+
+	indexes := make([][]int, 8)
+	wg := sync.WaitGroup{}
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				v := w*1000 + i
+				indexes[w] = append(indexes[w], col.Append(v))
+
+				// Readers run next to the appenders
+				got, ok := col.Get(indexes[w][i])
+				require.True(t, ok)
+				require.Equal(t, v, got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 4000, col.Len())
+
+	// Every index is handed out once, and still points at its item
+	seen := map[int]bool{}
+	for w, list := range indexes {
+		for i, index := range list {
+			require.False(t, seen[index])
+			seen[index] = true
+
+			v, ok := col.Get(index)
+			require.True(t, ok)
+			require.Equal(t, w*1000+i, v)
+		}
+	}
+}
@@ -3,29 +3,34 @@ package slices
 import (
 	"fmt"
 	"iter"
+	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"github.com/daanv2/go-kit/generics"
 )
 
 // Fixed is a slice of constant size, that can be used to store a fixed amount of items
+//
+// Appending is lock free amongst appenders: each reserves its slots with an atomic counter, writes them,
+// and publishes them in the order they were reserved, so readers never see slots that are still being written.
+// Changes that move items, such as deletes, take the write lock and wait for running appends to finish.
 type Fixed[T any] struct {
-	items []T          // The items in the slice
-	lock  sync.RWMutex // The lock to protect the slice
+	items    []T          // The items in the slice, the full capacity, only the first length are in use
+	reserved atomic.Int64 // The amount of slots handed out to appenders
+	length   atomic.Int64 // The amount of slots that have been written and are visible
+	lock     sync.RWMutex // Read locked by readers and appenders, write locked by changes that move or overwrite items
 }
 
 // Creates a new slice of fixed sized, if its full nothing can be added
 func NewFixed[T any](amount int) Fixed[T] {
 	return Fixed[T]{
-		items: make([]T, 0, amount),
+		items: make([]T, amount),
 	}
 }
 
 // Cap returns the capacity of the slice
 func (s *Fixed[T]) Cap() int {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
 	return cap(s.items)
 }
 
@@ -36,28 +41,22 @@ func (s *Fixed[T]) UnsafeCap() int {
 
 // Len returns the amount of items in the slice
 func (s *Fixed[T]) Len() int {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	return len(s.items)
+	return int(s.length.Load())
 }
 
 // UnsafeLen returns the amount of items in the slice without locking
 func (s *Fixed[T]) UnsafeLen() int {
-	return len(s.items)
+	return int(s.length.Load())
 }
 
-// SpaceLeft returns the amount of space left in the slice
+// SpaceLeft returns the amount of space left in the slice, including slots that are reserved but not yet written
 func (s *Fixed[T]) SpaceLeft() int {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	return cap(s.items) - len(s.items)
+	return cap(s.items) - int(s.reserved.Load())
 }
 
 // UnsafeSpaceLeft returns the amount of space left in the slice without locking
 func (s *Fixed[T]) UnsafeSpaceLeft() int {
-	return cap(s.items) - len(s.items)
+	return s.SpaceLeft()
 }
 
 // IsFull returns if the slice is full
@@ -80,7 +79,7 @@ func (s *Fixed[T]) Get(index int) (T, bool) {
 
 // UnsafeGet returns the item at the given index, and if it exists without locking
 func (s *Fixed[T]) UnsafeGet(index int) (T, bool) {
-	if index >= len(s.items) {
+	if index < 0 || index >= s.UnsafeLen() {
 		return generics.Empty[T](), false
 	}
 
@@ -97,7 +96,7 @@ func (s *Fixed[T]) Set(index int, value T) bool {
 
 // UnsafeSet will set the item at the given index, and return if it was successful without locking
 func (s *Fixed[T]) UnsafeSet(index int, value T) bool {
-	if index >= len(s.items) {
+	if index < 0 || index >= s.UnsafeLen() {
 		return false
 	}
 
@@ -110,7 +109,9 @@ func (s *Fixed[T]) Clear() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.items = s.items[:0]
+	clear(s.items[:s.UnsafeLen()])
+	s.length.Store(0)
+	s.reserved.Store(0)
 }
 
 // Delete will remove the item at the given index, and return if it was successful
@@ -123,11 +124,13 @@ func (s *Fixed[T]) Delete(index int) bool {
 
 // UnsafeDelete will remove the item at the given index, and return if it was successful without locking
 func (s *Fixed[T]) UnsafeDelete(index int) bool {
-	if index >= len(s.items) {
+	l := s.UnsafeLen()
+	if index < 0 || index >= l {
 		return false
 	}
 
-	s.items = append(s.items[:index], s.items[index+1:]...)
+	copy(s.items[index:], s.items[index+1:l])
+	s.truncate(l - 1)
 	return true
 }
 
@@ -135,24 +138,35 @@ func (s *Fixed[T]) UnsafeDelete(index int) bool {
 func (s *Fixed[T]) DeleteFunc(predicate func(v T) bool) int {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	amount := 0
 
-	for i, v := range s.items {
-		if predicate(v) {
-			s.items = append(s.items[:i], s.items[i+1:]...)
-			amount++
+	l := s.UnsafeLen()
+	kept := 0
+	for i := range l {
+		if predicate(s.items[i]) {
+			continue
 		}
+
+		s.items[kept] = s.items[i]
+		kept++
 	}
 
-	return amount
+	s.truncate(kept)
+	return l - kept
+}
+
+// truncate shortens the slice to l items, clearing the slots that are no longer in use. The caller is expected to hold the write lock
+func (s *Fixed[T]) truncate(l int) {
+	clear(s.items[l:s.UnsafeLen()])
+	s.length.Store(int64(l))
+	s.reserved.Store(int64(l))
 }
 
 // Find will return the first item that matches the predicate, and if it was found
 func (s *Fixed[T]) Find(predicate func(v T) bool) (T, bool) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 
-	for _, v := range s.items {
+	for _, v := range s.items[:s.UnsafeLen()] {
 		if predicate(v) {
 			return v, true
 		}
@@ -163,10 +177,10 @@ func (s *Fixed[T]) Find(predicate func(v T) bool) (T, bool) {
 
 // FindIndex will return the index of the first item that matches the predicate, and if it was found
 func (s *Fixed[T]) FindIndex(predicate func(v T) bool) (int, bool) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 
-	for i, v := range s.items {
+	for i, v := range s.items[:s.UnsafeLen()] {
 		if predicate(v) {
 			return i, true
 		}
@@ -177,22 +191,50 @@ func (s *Fixed[T]) FindIndex(predicate func(v T) bool) (int, bool) {
 
 // TryAppend will check how much space is left, and attempt to write as much as possible from the given data into its own buffer
 //
-// If you have 5 items, and there is room for 3, it will return 3, and has added 3 items to its buffer
+// If you have 5 items, and there is room for 3, it will return 3, and has added 3 items to its buffer.
+// Appenders do not block each other, the items of a single call end up next to each other.
 func (s *Fixed[T]) TryAppend(items ...T) int {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	_, amount := s.append(items)
+	return amount
+}
+
+// Append adds the item, returning the index it was placed at, and false if the slice is full.
+// The index stays valid until items are deleted.
+func (s *Fixed[T]) Append(item T) (int, bool) {
+	start, amount := s.append([]T{item})
+	return start, amount == 1
+}
+
+// append reserves slots for as many of the items as fit, writes and publishes them, returning the first index and the amount written
+func (s *Fixed[T]) append(items []T) (int, int) {
+	if len(items) == 0 {
+		return -1, 0
+	}
 
-	// Check if we have space and how much
-	space := s.UnsafeSpaceLeft()
-	if space <= 0 {
-		return 0
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	// Reserve the slots
+	var start, amount int64
+	for {
+		start = s.reserved.Load()
+		amount = min(int64(len(items)), int64(cap(s.items))-start)
+		if amount <= 0 {
+			return -1, 0
+		}
+		if s.reserved.CompareAndSwap(start, start+amount) {
+			break
+		}
 	}
-	if space < len(items) {
-		items = items[:space]
+
+	copy(s.items[start:start+amount], items)
+
+	// Publish in the order of reservation, so the length never covers slots that are still being written
+	for !s.length.CompareAndSwap(start, start+amount) {
+		runtime.Gosched()
 	}
 
-	s.items = append(s.items, items...)
-	return len(items)
+	return int(start), int(amount)
 }
 
 // Read will return a sequence of the items in the slice
@@ -201,7 +243,7 @@ func (s *Fixed[T]) Read() iter.Seq[T] {
 		s.lock.RLock()
 		defer s.lock.RUnlock()
 
-		for _, item := range s.items {
+		for _, item := range s.items[:s.UnsafeLen()] {
 			if !yield(item) {
 				return
 			}
@@ -210,10 +252,7 @@ func (s *Fixed[T]) Read() iter.Seq[T] {
 }
 
 func (s *Fixed[T]) String() string {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	return fmt.Sprintf("fixed.Slice[%s,%d/%d]", generics.NameOf[T](), len(s.items), cap(s.items))
+	return fmt.Sprintf("fixed.Slice[%s,%d/%d]", generics.NameOf[T](), s.Len(), s.Cap())
 }
 
 func (s *Fixed[T]) GoString() string {
@@ -78,3 +78,74 @@ func Test_Slice_Parralel(t *testing.T) {
 	require.EqualValues(t, col.Len(), 90)
 	require.EqualValues(t, total.Load(), 90)
 }
+
+func Test_Slice_DeleteFunc(t *testing.T) {
+	col := slices.NewFixed[int](10)
+	require.Equal(t, 8, col.TryAppend(1, 2, 2, 2, 3, 4, 4, 5))
+
+	// Neighbouring matches are all removed
+	require.Equal(t, 5, col.DeleteFunc(func(v int) bool { return v == 2 || v == 4 }))
+	require.Equal(t, []int{1, 3, 5}, collect(&col))
+	require.Equal(t, 7, col.SpaceLeft())
+
+	require.True(t, col.Delete(1))
+	require.False(t, col.Delete(2))
+	require.Equal(t, []int{1, 5}, collect(&col))
+
+	i, ok := col.FindIndex(func(v int) bool { return v == 5 })
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+
+	col.Clear()
+	require.Equal(t, 0, col.Len())
+	require.Equal(t, 10, col.TryAppend(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11))
+}
+
+func Test_Slice_Parralel_Contents(t *testing.T) {
+	col := slices.NewFixed[int](999)
+	wg := sync.WaitGroup{}
+
+	for w := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 40 {
+				base := (w*40 + i) * 3
+				col.TryAppend(base, base+1, base+2)
+			}
+		}()
+
+		// Readers run next to the appenders
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 40 {
+				col.Find(func(v int) bool { return v < 0 })
+				for range col.Read() {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every call ends up as a run of its own items
+	items := collect(&col)
+	require.Len(t, items, 999)
+	seen := map[int]bool{}
+	for i := 0; i < len(items); i += 3 {
+		require.Equal(t, 0, items[i]%3)
+		require.Equal(t, items[i]+1, items[i+1])
+		require.Equal(t, items[i]+2, items[i+2])
+		seen[items[i]] = true
+	}
+	require.Len(t, seen, 333)
+}
+
+func collect(col *slices.Fixed[int]) []int {
+	result := []int{}
+	for v := range col.Read() {
+		result = append(result, v)
+	}
+
+	return result
+}
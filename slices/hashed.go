@@ -4,23 +4,32 @@ import (
 	"fmt"
 	"iter"
 	"sync"
+	"sync/atomic"
 
+	"github.com/daanv2/go-cache/pkg/bloomfilters"
 	"github.com/daanv2/go-cache/pkg/hash"
 	"github.com/daanv2/go-kit/generics"
 )
 
 // FixedHashed is a fixed size slice, that can be used to store a fixed amount of items
+//
+// The hashes of the items are kept in a [bloomfilters.Counting], updated with every change,
+// so [FixedHashed.HasHash] can skip slices that do not hold a hash without taking the lock.
+// Rebuilding the summary swaps in a new filter, so readers never see it partially filled.
 type FixedHashed[T hash.Hashed] struct {
-	items     []T          // The items in the slice
-	lock      sync.RWMutex // The lock to protect the slic	e
-	hashrange hash.Range
+	items  []T                                    // The items in the slice
+	lock   sync.RWMutex                           // The lock to protect the slice
+	hashes *atomic.Pointer[bloomfilters.Counting] // The hashes of the items in the slice
 }
 
 // Creates a new slice of fixed sized, if its full nothing can be added
 func NewFixedHashed[T hash.Hashed](amount int) FixedHashed[T] {
+	hashes := &atomic.Pointer[bloomfilters.Counting]{}
+	hashes.Store(bloomfilters.NewCounting(uint64(max(amount, 0))))
+
 	return FixedHashed[T]{
-		items:     make([]T, 0, amount),
-		hashrange: hash.NewRange(),
+		items:  make([]T, 0, amount),
+		hashes: hashes,
 	}
 }
 
@@ -83,7 +92,7 @@ func (s *FixedHashed[T]) Get(index int) (T, bool) {
 
 // UnsafeGet returns the item at the given index, and if it exists without locking
 func (s *FixedHashed[T]) UnsafeGet(index int) (T, bool) {
-	if index >= len(s.items) {
+	if index < 0 || index >= len(s.items) {
 		return generics.Empty[T](), false
 	}
 
@@ -100,17 +109,15 @@ func (s *FixedHashed[T]) Set(index int, value T) bool {
 
 // UnsafeSet will set the item at the given index, and return if it was successful without locking
 func (s *FixedHashed[T]) UnsafeSet(index int, value T) bool {
-	if index >= len(s.items) {
+	if index < 0 || index >= len(s.items) {
 		return false
 	}
 
-	old := s.items[index]
+	// Add before removing, so the hash is never missing when it stays the same
+	s.hashes.Load().Add(value.Hash())
+	s.hashes.Load().Remove(s.items[index].Hash())
 	s.items[index] = value
 
-	if old.Hash() != value.Hash() {
-		s.Rehash()
-	}
-
 	return true
 }
 
@@ -119,7 +126,9 @@ func (s *FixedHashed[T]) Clear() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	clear(s.items)
 	s.items = s.items[:0]
+	s.hashes.Store(bloomfilters.NewCounting(uint64(cap(s.items))))
 }
 
 // Delete will remove the item at the given index, and return if it was successful
@@ -132,12 +141,15 @@ func (s *FixedHashed[T]) Delete(index int) bool {
 
 // UnsafeDelete will remove the item at the given index, and return if it was successful without locking
 func (s *FixedHashed[T]) UnsafeDelete(index int) bool {
-	if index >= len(s.items) {
+	l := len(s.items)
+	if index < 0 || index >= l {
 		return false
 	}
 
-	s.items = append(s.items[:index], s.items[index+1:]...)
-	s.Rehash()
+	s.hashes.Load().Remove(s.items[index].Hash())
+	copy(s.items[index:], s.items[index+1:])
+	clear(s.items[l-1:])
+	s.items = s.items[:l-1]
 	return true
 }
 
@@ -145,23 +157,29 @@ func (s *FixedHashed[T]) UnsafeDelete(index int) bool {
 func (s *FixedHashed[T]) DeleteFunc(predicate func(v T) bool) int {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	amount := 0
 
-	for i, v := range s.items {
+	kept := 0
+	for _, v := range s.items {
 		if predicate(v) {
-			s.items = append(s.items[:i], s.items[i+1:]...)
-			amount++
+			s.hashes.Load().Remove(v.Hash())
+			continue
 		}
+
+		s.items[kept] = v
+		kept++
 	}
-	s.Rehash()
+
+	amount := len(s.items) - kept
+	clear(s.items[kept:])
+	s.items = s.items[:kept]
 
 	return amount
 }
 
 // Find will return the first item that matches the predicate, and if it was found
 func (s *FixedHashed[T]) Find(predicate func(v T) bool) (T, bool) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 
 	for _, v := range s.items {
 		if predicate(v) {
@@ -174,8 +192,8 @@ func (s *FixedHashed[T]) Find(predicate func(v T) bool) (T, bool) {
 
 // FindIndex will return the index of the first item that matches the predicate, and if it was found
 func (s *FixedHashed[T]) FindIndex(predicate func(v T) bool) (int, bool) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 
 	for i, v := range s.items {
 		if predicate(v) {
@@ -204,7 +222,7 @@ func (s *FixedHashed[T]) TryAppend(items ...T) int {
 
 	for _, item := range items {
 		s.items = append(s.items, item)
-		s.hashrange.Update(item.Hash())
+		s.hashes.Load().Add(item.Hash())
 	}
 
 	return len(items)
@@ -224,19 +242,21 @@ func (s *FixedHashed[T]) Read() iter.Seq[T] {
 	}
 }
 
+// Rehash rebuilds the hash summary from the items, for items whose hash changed while they were in the slice
 func (s *FixedHashed[T]) Rehash() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	nr := hash.NewRange()
+	hashes := bloomfilters.NewCounting(uint64(cap(s.items)))
 	for _, item := range s.items {
-		nr.Update(item.Hash())
+		hashes.Add(item.Hash())
 	}
-	s.hashrange = nr
+	s.hashes.Store(hashes)
 }
 
+// HasHash returns false if no item in the slice has the hash, without taking the lock
 func (s *FixedHashed[T]) HasHash(v uint64) bool {
-	return s.hashrange.Has(v)
+	return s.hashes.Load().Has(v)
 }
 
 func (s *FixedHashed[T]) String() string {
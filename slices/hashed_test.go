@@ -0,0 +1,115 @@
+package slices_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/slices"
+	"github.com/stretchr/testify/require"
+)
+
+type hashedItem uint64
+
+func (h hashedItem) Hash() uint64 {
+	return uint64(h)
+}
+
+func Test_FixedHashed(t *testing.T) {
+	col := slices.NewFixedHashed[hashedItem](10)
+	require.Equal(t, 5, col.TryAppend(10, 20, 30, 30, 40))
+	require.True(t, col.HasHash(20))
+
+	// Changing the hash of an item updates the summary
+	require.True(t, col.Set(1, 25))
+	require.False(t, col.HasHash(20))
+	require.True(t, col.HasHash(25))
+
+	require.True(t, col.Delete(0))
+	require.False(t, col.HasHash(10))
+
+	// Neighbouring matches are all removed, the duplicate hash only once both are gone
+	require.Equal(t, 2, col.DeleteFunc(func(v hashedItem) bool { return v == 30 }))
+	require.False(t, col.HasHash(30))
+	require.Equal(t, 2, col.Len())
+
+	col.Clear()
+	require.False(t, col.HasHash(25))
+	require.False(t, col.HasHash(40))
+	require.Equal(t, 10, col.SpaceLeft())
+}
+
+func Test_FixedHashed_Parralel(t *testing.T) {
+	col := slices.NewFixedHashed[hashedItem](100)
+	wg := sync.WaitGroup{}
+
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				v := hashedItem(w*1000 + i)
+				col.TryAppend(v)
+				col.Set(i%10, v+1)
+				col.HasHash(uint64(v))
+				col.Find(func(item hashedItem) bool { return item == v })
+				col.FindIndex(func(item hashedItem) bool { return item == v })
+				col.Delete(i % 7)
+				col.DeleteFunc(func(item hashedItem) bool { return item%13 == 0 })
+				if i%50 == 0 {
+					col.Clear()
+					col.Rehash()
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlocked")
+	}
+
+	// The summary matches the items that are left
+	for v := range col.Read() {
+		require.True(t, col.HasHash(uint64(v)))
+	}
+}
+
+func Test_FixedHashed_RehashWhileReading(t *testing.T) {
+	col := slices.NewFixedHashed[hashedItem](100)
+	for i := range 100 {
+		col.TryAppend(hashedItem(i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 1000 {
+			col.Rehash()
+		}
+	}()
+
+	// Readers never see a partially rebuilt summary
+	missing := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		for i := range 100 {
+			if !col.HasHash(uint64(i)) {
+				missing++
+			}
+		}
+	}
+	require.Zero(t, missing)
+}
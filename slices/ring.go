@@ -0,0 +1,275 @@
+package slices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-kit/generics"
+)
+
+// ErrClosed is returned when pushing to a closed [Ring], or popping from one that is closed and empty
+var ErrClosed = errors.New("ring is closed")
+
+// RingOptions are the options for a [Ring].
+type RingOptions struct {
+	overwrite bool
+}
+
+// CreateRingOptions creates the options for a [Ring], pushing to a full ring fails or blocks by default.
+func CreateRingOptions(opts ...options.Option[RingOptions]) (RingOptions, error) {
+	op := RingOptions{
+		overwrite: false,
+	}
+
+	err := options.Apply(&op, opts...)
+
+	return op, err
+}
+
+// WithOverwrite makes pushing to a full ring drop the oldest item instead, so pushes never fail or block
+func WithOverwrite() options.Option[RingOptions] {
+	return options.NewFunction(func(option *RingOptions) {
+		option.overwrite = true
+	})
+}
+
+// cell is a slot of the ring, its sequence tells pushers and poppers whose turn it is.
+// The sequence is 2*pos while the slot is free for the push at pos, and 2*pos+1 once that push wrote it,
+// doubled so a ring of a single slot can tell a written slot from a free one.
+type cell[T any] struct {
+	sequence atomic.Uint64
+	value    T
+}
+
+// Ring is a fixed capacity queue that many goroutines can push to and pop from at the same time, without locks.
+//
+// Every slot carries a sequence number, a pusher claims the slot at the tail when the sequence says it is free,
+// and a popper claims the slot at the head when the sequence says it was written, see Vyukov's bounded MPMC queue.
+// The blocking [Ring.Push] and [Ring.Pop] wait on signals sent by the other side.
+type Ring[T any] struct {
+	RingOptions
+	cells     []cell[T]
+	head      atomic.Uint64 // The position of the next pop
+	tail      atomic.Uint64 // The position of the next push
+	dropped   atomic.Uint64 // The amount of items dropped by overwriting
+	not_empty chan struct{}
+	not_full  chan struct{}
+	closed    atomic.Bool
+	done      chan struct{}
+}
+
+// NewRing creates a new Ring that holds up to capacity items
+func NewRing[T any](capacity int, opts ...options.Option[RingOptions]) (*Ring[T], error) {
+	if capacity <= 0 {
+		return nil, errors.New("capacity has to be larger than 0")
+	}
+
+	base, err := CreateRingOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Ring[T]{
+		RingOptions: base,
+		cells:       make([]cell[T], capacity),
+		not_empty:   make(chan struct{}, 1),
+		not_full:    make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	for i := range r.cells {
+		r.cells[i].sequence.Store(2 * uint64(i))
+	}
+
+	return r, nil
+}
+
+// Cap returns the amount of items the ring can hold
+func (r *Ring[T]) Cap() int {
+	return len(r.cells)
+}
+
+// Len returns the amount of items in the ring, which may already be outdated when it returns
+func (r *Ring[T]) Len() int {
+	tail, head := r.tail.Load(), r.head.Load()
+	if tail <= head {
+		return 0
+	}
+
+	return min(int(tail-head), len(r.cells))
+}
+
+// Dropped returns the amount of items that were dropped to make room, see [WithOverwrite]
+func (r *Ring[T]) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// TryPush adds the item to the ring, and returns false if it is full or closed.
+// With [WithOverwrite] the oldest item is dropped to make room instead.
+func (r *Ring[T]) TryPush(item T) bool {
+	if r.closed.Load() {
+		return false
+	}
+
+	for {
+		if r.push(item) {
+			signal(r.not_empty)
+			return true
+		}
+		if !r.overwrite {
+			return false
+		}
+
+		if _, ok := r.pop(); ok {
+			r.dropped.Add(1)
+		}
+	}
+}
+
+// push claims the slot at the tail and writes the item in it, false if the ring is full
+func (r *Ring[T]) push(item T) bool {
+	size := uint64(len(r.cells))
+
+	for {
+		pos := r.tail.Load()
+		c := &r.cells[pos%size]
+		seq := c.sequence.Load()
+
+		switch {
+		case seq == 2*pos:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				c.value = item
+				c.sequence.Store(2*pos + 1)
+				return true
+			}
+		case seq < 2*pos:
+			// The slot still holds the item from the previous lap
+			return false
+		}
+	}
+}
+
+// TryPop removes and returns the oldest item of the ring, false if it is empty
+func (r *Ring[T]) TryPop() (T, bool) {
+	item, ok := r.pop()
+	if ok {
+		signal(r.not_full)
+	}
+
+	return item, ok
+}
+
+// pop claims the slot at the head and takes the item out of it, false if the ring is empty
+func (r *Ring[T]) pop() (T, bool) {
+	size := uint64(len(r.cells))
+
+	for {
+		pos := r.head.Load()
+		c := &r.cells[pos%size]
+		seq := c.sequence.Load()
+
+		switch {
+		case seq == 2*pos+1:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				item := c.value
+				c.value = generics.Empty[T]()
+				c.sequence.Store(2 * (pos + size))
+				return item, true
+			}
+		case seq < 2*pos+1:
+			// The slot has not been written yet
+			return generics.Empty[T](), false
+		}
+	}
+}
+
+// Push adds the item to the ring, waiting for room until the context is done or the ring is closed
+func (r *Ring[T]) Push(ctx context.Context, item T) error {
+	for {
+		if r.closed.Load() {
+			return ErrClosed
+		}
+		if r.TryPush(item) {
+			// Pass the signal on to other waiting pushers if there is room left
+			if r.Len() < r.Cap() {
+				signal(r.not_full)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.done:
+		case <-r.not_full:
+		}
+	}
+}
+
+// Pop removes and returns the oldest item of the ring, waiting for one until the context is done.
+// Once the ring is closed the remaining items are returned, followed by [ErrClosed].
+func (r *Ring[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		if item, ok := r.TryPop(); ok {
+			// Pass the signal on to other waiting poppers if there are items left
+			if r.Len() > 0 {
+				signal(r.not_empty)
+			}
+			return item, nil
+		}
+		if r.closed.Load() && r.Len() == 0 {
+			return generics.Empty[T](), ErrClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return generics.Empty[T](), ctx.Err()
+		case <-r.done:
+		case <-r.not_empty:
+		}
+	}
+}
+
+// Drain pops items into buf until it is full or the ring is empty, returning the amount of items popped
+func (r *Ring[T]) Drain(buf []T) int {
+	n := 0
+	for n < len(buf) {
+		item, ok := r.pop()
+		if !ok {
+			break
+		}
+
+		buf[n] = item
+		n++
+	}
+	if n > 0 {
+		signal(r.not_full)
+	}
+
+	return n
+}
+
+// Close stops the ring from accepting items, waking all waiting pushers and poppers. Items still in the ring can be popped
+func (r *Ring[T]) Close() {
+	if r.closed.CompareAndSwap(false, true) {
+		close(r.done)
+	}
+}
+
+// signal wakes a waiter on the channel, if none is waiting the signal is kept for the next one
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Ring[T]) String() string {
+	return fmt.Sprintf("slices.Ring[%s,%d/%d]", generics.NameOf[T](), r.Len(), r.Cap())
+}
+
+func (r *Ring[T]) GoString() string {
+	return r.String()
+}
@@ -0,0 +1,127 @@
+package slices_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/slices"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Ring(t *testing.T) {
+	ring, err := slices.NewRing[int](3)
+	require.NoError(t, err)
+
+	require.True(t, ring.TryPush(1))
+	require.True(t, ring.TryPush(2))
+	require.True(t, ring.TryPush(3))
+	require.False(t, ring.TryPush(4))
+	require.Equal(t, 3, ring.Len())
+
+	v, ok := ring.TryPop()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.True(t, ring.TryPush(4))
+
+	buf := make([]int, 10)
+	require.Equal(t, 3, ring.Drain(buf))
+	require.Equal(t, []int{2, 3, 4}, buf[:3])
+
+	_, ok = ring.TryPop()
+	require.False(t, ok)
+
+	_, err = slices.NewRing[int](0)
+	require.Error(t, err)
+}
+
+func Test_Ring_Overwrite(t *testing.T) {
+	ring, err := slices.NewRing[int](3, slices.WithOverwrite())
+	require.NoError(t, err)
+
+	for i := range 10 {
+		require.True(t, ring.TryPush(i))
+	}
+	require.Equal(t, uint64(7), ring.Dropped())
+
+	buf := make([]int, 3)
+	require.Equal(t, 3, ring.Drain(buf))
+	require.Equal(t, []int{7, 8, 9}, buf)
+}
+
+func Test_Ring_Blocking(t *testing.T) {
+	ring, err := slices.NewRing[int](1)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ring.Pop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, ring.Push(context.Background(), 1))
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, ring.Push(ctx, 2), context.DeadlineExceeded)
+
+	// A waiting pusher is woken by a pop
+	pushed := make(chan error)
+	go func() { pushed <- ring.Push(context.Background(), 2) }()
+	v, err := ring.Pop(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+	require.NoError(t, <-pushed)
+
+	// Closing keeps the remaining items poppable
+	ring.Close()
+	require.ErrorIs(t, ring.Push(context.Background(), 3), slices.ErrClosed)
+	v, err = ring.Pop(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+	_, err = ring.Pop(context.Background())
+	require.ErrorIs(t, err, slices.ErrClosed)
+}
+
+func Test_Ring_Parralel(t *testing.T) {
+	ring, err := slices.NewRing[int](16)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	producers := sync.WaitGroup{}
+	for p := range 8 {
+		producers.Add(1)
+		go func() {
+			defer producers.Done()
+			for i := range 1000 {
+				require.NoError(t, ring.Push(ctx, p*1000+i))
+			}
+		}()
+	}
+
+	results := make(chan []int, 4)
+	for range 4 {
+		go func() {
+			popped := []int{}
+			for {
+				v, err := ring.Pop(ctx)
+				if err != nil {
+					results <- popped
+					return
+				}
+				popped = append(popped, v)
+			}
+		}()
+	}
+
+	producers.Wait()
+	ring.Close()
+
+	seen := make(map[int]bool, 8000)
+	for range 4 {
+		for _, v := range <-results {
+			require.False(t, seen[v], v)
+			seen[v] = true
+		}
+	}
+	require.Len(t, seen, 8000)
+}
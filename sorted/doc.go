@@ -0,0 +1,3 @@
+// sorted is a package of collections that keep their keys in order, for range queries that the hash based maps and sets can not answer.
+// [Map] is a skiplist ordered by a compare function, see [NewMap], [NewComparableMap] and [NewMapFunc].
+package sorted
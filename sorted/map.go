@@ -0,0 +1,402 @@
+package sorted
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/daanv2/go-cache/pkg/constraints"
+	"github.com/daanv2/go-kit/generics"
+)
+
+const (
+	max_level = 32 // Enough levels for 4^32 keys
+	batch     = 64 // The amount of items an iterator reads under the lock at a time
+)
+
+// node is an entry of the skiplist. span[i] is the amount of entries that next[i] is ahead of this node
+type node[K, V any] struct {
+	key   K
+	value V
+	next  []*node[K, V]
+	span  []int
+	prev  *node[K, V] // The previous node on the lowest level, nil for the first
+}
+
+// Map is an ordered map, a skiplist that keeps its keys sorted by a compare function.
+//
+// Every link of the skiplist records how many entries it skips, so the rank of a key and the key at a rank are found in O(log n).
+// Readers share a read lock and writers take the write lock. Iterators read in batches and release the lock before yielding,
+// so the loop body may change the map, changes made while iterating may or may not be seen.
+type Map[K, V any] struct {
+	compare func(a, b K) int
+	head    *node[K, V]
+	tail    *node[K, V]
+	level   int
+	length  int
+	lock    sync.RWMutex
+}
+
+// NewMap creates a new Map for keys with a natural order
+func NewMap[K cmp.Ordered, V any]() *Map[K, V] {
+	m, _ := NewMapFunc[K, V](cmp.Compare[K])
+	return m
+}
+
+// NewComparableMap creates a new Map for keys that compare themselves, see [constraints.Comparable]
+func NewComparableMap[K constraints.Comparable[K], V any]() *Map[K, V] {
+	m, _ := NewMapFunc[K, V](func(a, b K) int { return a.Compare(b) })
+	return m
+}
+
+// NewMapFunc creates a new Map ordered by the compare function, which returns a negative number when a sorts before b, 0 if they are equal and a positive number otherwise
+func NewMapFunc[K, V any](compare func(a, b K) int) (*Map[K, V], error) {
+	if compare == nil {
+		return nil, errors.New("compare is nil")
+	}
+
+	return &Map[K, V]{
+		compare: compare,
+		head:    newNode[K, V](generics.Empty[K](), generics.Empty[V](), max_level),
+		tail:    nil,
+		level:   1,
+		length:  0,
+		lock:    sync.RWMutex{},
+	}, nil
+}
+
+func newNode[K, V any](key K, value V, level int) *node[K, V] {
+	return &node[K, V]{
+		key:   key,
+		value: value,
+		next:  make([]*node[K, V], level),
+		span:  make([]int, level),
+		prev:  nil,
+	}
+}
+
+// randomLevel returns the level of a new node, each level is 4 times less likely than the one below it
+func randomLevel() int {
+	return min(bits.TrailingZeros64(rand.Uint64())/2+1, max_level)
+}
+
+// Len returns the amount of items in the map
+func (m *Map[K, V]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.length
+}
+
+// Get returns the value for the key, and if it was found
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	n := m.ceiling(key)
+	if n == nil || m.compare(n.key, key) != 0 {
+		return generics.Empty[V](), false
+	}
+
+	return n.value, true
+}
+
+// Set will add or update the value for the key. It returns true if the value was added, false if it was updated.
+func (m *Map[K, V]) Set(key K, value V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var update [max_level]*node[K, V]
+	var rank [max_level]int
+
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		if i < m.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			rank[i] += x.span[i]
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	if n := x.next[0]; n != nil && m.compare(n.key, key) == 0 {
+		n.value = value
+		return false
+	}
+
+	level := randomLevel()
+	if level > m.level {
+		for i := m.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = m.head
+			update[i].span[i] = m.length
+		}
+		m.level = level
+	}
+
+	n := newNode(key, value, level)
+	for i := range level {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+
+		n.span[i] = update[i].span[i] - (rank[0] - rank[i])
+		update[i].span[i] = rank[0] - rank[i] + 1
+	}
+	// Links above the new node skip one more entry
+	for i := level; i < m.level; i++ {
+		update[i].span[i]++
+	}
+
+	if update[0] != m.head {
+		n.prev = update[0]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		m.tail = n
+	}
+
+	m.length++
+	return true
+}
+
+// Delete removes the key from the map. It returns the removed value and true if it was found.
+func (m *Map[K, V]) Delete(key K) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var update [max_level]*node[K, V]
+
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	n := x.next[0]
+	if n == nil || m.compare(n.key, key) != 0 {
+		return generics.Empty[V](), false
+	}
+
+	for i := range m.level {
+		if update[i].next[i] == n {
+			update[i].span[i] += n.span[i] - 1
+			update[i].next[i] = n.next[i]
+		} else {
+			update[i].span[i]--
+		}
+	}
+
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+
+	m.length--
+	return n.value, true
+}
+
+// ceiling returns the first node with a key at or after the key, the caller is expected to hold the lock
+func (m *Map[K, V]) ceiling(key K) *node[K, V] {
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+	}
+
+	return x.next[0]
+}
+
+// floor returns the last node with a key at or before the key, the caller is expected to hold the lock
+func (m *Map[K, V]) floor(key K) *node[K, V] {
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) <= 0 {
+			x = x.next[i]
+		}
+	}
+
+	if x == m.head {
+		return nil
+	}
+
+	return x
+}
+
+// Ceiling returns the item with the smallest key that is equal to or larger than the key, and false if there is none
+func (m *Map[K, V]) Ceiling(key K) (K, V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return unpack(m.ceiling(key))
+}
+
+// Floor returns the item with the largest key that is equal to or smaller than the key, and false if there is none
+func (m *Map[K, V]) Floor(key K) (K, V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return unpack(m.floor(key))
+}
+
+// Min returns the item with the smallest key, and false if the map is empty
+func (m *Map[K, V]) Min() (K, V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return unpack(m.head.next[0])
+}
+
+// Max returns the item with the largest key, and false if the map is empty
+func (m *Map[K, V]) Max() (K, V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return unpack(m.tail)
+}
+
+// Rank returns the amount of keys that are smaller than the key, which is the index of the key if it is in the map
+func (m *Map[K, V]) Rank(key K) int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	rank := 0
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			rank += x.span[i]
+			x = x.next[i]
+		}
+	}
+
+	return rank
+}
+
+// At returns the item at the index in the order of the keys, and false if the index is out of range
+func (m *Map[K, V]) At(index int) (K, V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if index < 0 || index >= m.length {
+		return unpack[K, V](nil)
+	}
+
+	// Ranks count from 1, the head is at 0
+	target, traversed := index+1, 0
+	x := m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && traversed+x.span[i] <= target {
+			traversed += x.span[i]
+			x = x.next[i]
+		}
+	}
+
+	return unpack(x)
+}
+
+func unpack[K, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		return generics.Empty[K](), generics.Empty[V](), false
+	}
+
+	return n.key, n.value, true
+}
+
+// Ascend returns a sequence of the items with from <= key < to, smallest key first
+func (m *Map[K, V]) Ascend(from, to K) iter.Seq2[K, V] {
+	return m.ascend(func() *node[K, V] { return m.ceiling(from) }, func(key K) bool { return m.compare(key, to) < 0 })
+}
+
+// All returns a sequence of all items, smallest key first
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return m.ascend(func() *node[K, V] { return m.head.next[0] }, func(key K) bool { return true })
+}
+
+// Descend returns a sequence of the items with from >= key > to, largest key first
+func (m *Map[K, V]) Descend(from, to K) iter.Seq2[K, V] {
+	return m.descend(func() *node[K, V] { return m.floor(from) }, func(key K) bool { return m.compare(key, to) > 0 })
+}
+
+// Backward returns a sequence of all items, largest key first
+func (m *Map[K, V]) Backward() iter.Seq2[K, V] {
+	return m.descend(func() *node[K, V] { return m.tail }, func(key K) bool { return true })
+}
+
+// item is a copy of a node, taken while holding the lock
+type item[K, V any] struct {
+	key   K
+	value V
+}
+
+// ascend yields the items from the first node onwards while within is true, reading a batch at a time
+func (m *Map[K, V]) ascend(first func() *node[K, V], within func(key K) bool) iter.Seq2[K, V] {
+	return m.walk(first, within, func(n *node[K, V]) *node[K, V] { return n.next[0] }, func(last K) *node[K, V] {
+		// The first node after the last one yielded, which may have been removed since
+		n := m.ceiling(last)
+		if n != nil && m.compare(n.key, last) == 0 {
+			n = n.next[0]
+		}
+		return n
+	})
+}
+
+// descend yields the items from the first node backwards while within is true, reading a batch at a time
+func (m *Map[K, V]) descend(first func() *node[K, V], within func(key K) bool) iter.Seq2[K, V] {
+	return m.walk(first, within, func(n *node[K, V]) *node[K, V] { return n.prev }, func(last K) *node[K, V] {
+		n := m.floor(last)
+		if n != nil && m.compare(n.key, last) == 0 {
+			n = n.prev
+		}
+		return n
+	})
+}
+
+// walk copies batches of items under the read lock and yields them without it, resuming after the last key yielded
+func (m *Map[K, V]) walk(first func() *node[K, V], within func(key K) bool, step func(n *node[K, V]) *node[K, V], resume func(last K) *node[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		items := make([]item[K, V], 0, batch)
+		start := first
+
+		for {
+			items = items[:0]
+			m.lock.RLock()
+			for n := start(); n != nil && len(items) < batch && within(n.key); n = step(n) {
+				items = append(items, item[K, V]{n.key, n.value})
+			}
+			m.lock.RUnlock()
+
+			for _, item := range items {
+				if !yield(item.key, item.value) {
+					return
+				}
+			}
+			if len(items) < batch {
+				return
+			}
+
+			last := items[len(items)-1].key
+			start = func() *node[K, V] { return resume(last) }
+		}
+	}
+}
+
+func (m *Map[K, V]) String() string {
+	return fmt.Sprintf("sorted.Map[%s,%s]", generics.NameOf[K](), generics.NameOf[V]())
+}
+
+func (m *Map[K, V]) GoString() string {
+	return m.String()
+}
@@ -0,0 +1,159 @@
+package sorted_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/daanv2/go-cache/sorted"
+	"github.com/stretchr/testify/require"
+)
+
+func collect[K, V any](seq func(yield func(K, V) bool)) []K {
+	keys := []K{}
+	for k := range seq {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func Test_Map_Reference(t *testing.T) {
+	col := sorted.NewMap[int, string]()
+	reference := map[int]bool{}
+	r := rand.New(rand.NewPCG(1, 2))
+
+	for range 5000 {
+		k := r.IntN(1000)
+		if r.IntN(3) == 0 {
+			_, ok := col.Delete(k)
+			require.Equal(t, reference[k], ok)
+			delete(reference, k)
+		} else {
+			require.Equal(t, !reference[k], col.Set(k, "value"))
+			reference[k] = true
+		}
+	}
+
+	keys := make([]int, 0, len(reference))
+	for k := range reference {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	require.Equal(t, len(keys), col.Len())
+	require.Equal(t, keys, collect(col.All()))
+
+	backward := slices.Clone(keys)
+	slices.Reverse(backward)
+	require.Equal(t, backward, collect(col.Backward()))
+
+	for i, k := range keys {
+		require.Equal(t, i, col.Rank(k))
+		at, _, ok := col.At(i)
+		require.True(t, ok)
+		require.Equal(t, k, at)
+	}
+	_, _, ok := col.At(len(keys))
+	require.False(t, ok)
+
+	for k := -1; k <= 1001; k++ {
+		i, found := slices.BinarySearch(keys, k)
+		require.Equal(t, i, col.Rank(k))
+
+		ceiling, _, ok := col.Ceiling(k)
+		require.Equal(t, i < len(keys), ok)
+		if ok {
+			require.Equal(t, keys[i], ceiling)
+		}
+
+		floor, _, ok := col.Floor(k)
+		if found {
+			require.Equal(t, k, floor)
+		} else {
+			require.Equal(t, i > 0, ok)
+			if ok {
+				require.Equal(t, keys[i-1], floor)
+			}
+		}
+	}
+
+	// Ranges cross the batches the iterators read
+	lo, hi := slices.Index(keys, keys[10]), slices.Index(keys, keys[300])
+	require.Equal(t, keys[lo:hi], collect(col.Ascend(keys[10], keys[300])))
+	down := slices.Clone(keys[lo+1 : hi+1])
+	slices.Reverse(down)
+	require.Equal(t, down, collect(col.Descend(keys[300], keys[10])))
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v version) Compare(other version) int {
+	if v.major != other.major {
+		return v.major - other.major
+	}
+
+	return v.minor - other.minor
+}
+
+func Test_ComparableMap(t *testing.T) {
+	col := sorted.NewComparableMap[version, string]()
+	col.Set(version{1, 2}, "b")
+	col.Set(version{1, 0}, "a")
+	col.Set(version{2, 0}, "c")
+
+	require.Equal(t, []version{{1, 0}, {1, 2}, {2, 0}}, collect(col.All()))
+	k, v, ok := col.Floor(version{1, 9})
+	require.True(t, ok)
+	require.Equal(t, version{1, 2}, k)
+	require.Equal(t, "b", v)
+
+	min, _, _ := col.Min()
+	require.Equal(t, version{1, 0}, min)
+	max, _, _ := col.Max()
+	require.Equal(t, version{2, 0}, max)
+
+	_, err := sorted.NewMapFunc[int, int](nil)
+	require.Error(t, err)
+}
+
+func Test_Map_Parralel(t *testing.T) {
+	col := sorted.NewMap[int, int]()
+	wg := sync.WaitGroup{}
+
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				col.Set(i*4+w, w)
+				if i%3 == 0 {
+					col.Delete(i*4 + w)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				previous := -1
+				for k := range col.All() {
+					require.Greater(t, k, previous)
+					previous = k
+				}
+				col.Rank(1000)
+				col.Floor(1000)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The loop body may change the map
+	for k := range col.All() {
+		col.Delete(k)
+	}
+	require.Equal(t, 0, col.Len())
+}
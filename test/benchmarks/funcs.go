@@ -1,11 +1,13 @@
 package benchmarks
 
 import (
+	"context"
 	"runtime"
 	"sync"
 	"testing"
 
 	"github.com/daanv2/go-cache/pkg/collections"
+	"github.com/daanv2/go-cache/slices"
 	"github.com/daanv2/go-optimal/pkg/cpu"
 )
 
@@ -33,7 +35,7 @@ var (
 
 func PumpConcurrentSet[T any](set GetOrAdd[T], items []T) {
 	wg := &sync.WaitGroup{}
-	pump := make(chan T, buffer)
+	pump := newPump[T]()
 
 	go transferThenClose(pump, items)
 
@@ -48,7 +50,7 @@ func PumpConcurrentSet[T any](set GetOrAdd[T], items []T) {
 
 func PumpConcurrentMap[K, V comparable](set Map[K, V], items []KeyValue[K, V]) {
 	wg := &sync.WaitGroup{}
-	pump := make(chan KeyValue[K, V], buffer)
+	pump := newPump[KeyValue[K, V]]()
 
 	go transferThenClose(pump, items)
 
@@ -61,7 +63,16 @@ func PumpConcurrentMap[K, V comparable](set Map[K, V], items []KeyValue[K, V]) {
 	wg.Wait()
 }
 
-func transferThenClose[T any](pump chan<- T, items []T) {
+func newPump[T any]() *slices.Ring[T] {
+	pump, err := slices.NewRing[T](buffer)
+	if err != nil {
+		panic(err)
+	}
+
+	return pump
+}
+
+func transferThenClose[T any](pump *slices.Ring[T], items []T) {
 	l := len(items)
 	step := max(l*procs, 10)
 	wg := &sync.WaitGroup{}
@@ -73,14 +84,14 @@ func transferThenClose[T any](pump chan<- T, items []T) {
 	}
 
 	wg.Wait()
-	close(pump)
+	pump.Close()
 }
 
-func transfer[T any](wg *sync.WaitGroup, pump chan<- T, items []T) {
+func transfer[T any](wg *sync.WaitGroup, pump *slices.Ring[T], items []T) {
 	defer wg.Done()
 
 	for _, item := range items {
-		pump <- item
+		_ = pump.Push(context.Background(), item)
 	}
 }
 
@@ -96,14 +107,24 @@ func PumpSyncMap[K, V comparable](set Map[K, V], items []KeyValue[K, V]) {
 	}
 }
 
-func PumpIntoSet[T any](set GetOrAdd[T], pump <-chan T) {
-	for item := range pump {
+func PumpIntoSet[T any](set GetOrAdd[T], pump *slices.Ring[T]) {
+	for {
+		item, err := pump.Pop(context.Background())
+		if err != nil {
+			return
+		}
+
 		_, _ = set.GetOrAdd(item)
 	}
 }
 
-func PumpIntoMap[K, V comparable](set Map[K, V], pump <-chan KeyValue[K, V]) {
-	for item := range pump {
+func PumpIntoMap[K, V comparable](set Map[K, V], pump *slices.Ring[KeyValue[K, V]]) {
+	for {
+		item, err := pump.Pop(context.Background())
+		if err != nil {
+			return
+		}
+
 		_ = set.Set(item.GetKey(), item.GetValue())
 	}
 }
@@ -145,13 +166,13 @@ func ReportAdd(t *testing.B, size uint64) {
 	t.ReportMetric(inserts, "inserts")
 }
 
-func PumpIntoSyncedSet[T any](wg *sync.WaitGroup, set GetOrAdd[T], pump <-chan T) {
+func PumpIntoSyncedSet[T any](wg *sync.WaitGroup, set GetOrAdd[T], pump *slices.Ring[T]) {
 	defer wg.Done()
 
 	PumpIntoSet(set, pump)
 }
 
-func PumpIntoSyncedMap[K, V comparable](wg *sync.WaitGroup, set Map[K, V], pump <-chan KeyValue[K, V]) {
+func PumpIntoSyncedMap[K, V comparable](wg *sync.WaitGroup, set Map[K, V], pump *slices.Ring[KeyValue[K, V]]) {
 	defer wg.Done()
 
 	PumpIntoMap(set, pump)
@@ -0,0 +1,92 @@
+package large_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/large"
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/test/benchmarks"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Benchmark_Large_Map(b *testing.B) {
+	sizes := []uint64{10_000, 100_000}
+	hasher := test_util.CheapIntHasher[int]()
+
+	test_util.Case1(sizes, func(size uint64) {
+		items := test_util.Generate(int(size))
+		col, err := large.NewMap[int, string](size, hasher)
+		require.NoError(b, err)
+
+		b.Run(fmt.Sprintf("Set(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, item := range items {
+					_, err := col.Set(item.ID, item.Data)
+					if err != nil {
+						t.Fail()
+					}
+				}
+			}
+
+			benchmarks.ReportAdd(t, size)
+		})
+
+		b.Run(fmt.Sprintf("Get(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, item := range items {
+					v, ok := col.Get(item.ID)
+					if !ok || v.Value == "" {
+						t.Fail()
+					}
+				}
+			}
+
+			benchmarks.ReportAdd(t, size)
+		})
+	})
+}
+
+// Benchmark_GC compares the time a garbage collection takes with a filled large.Map against a filled maps.Bucketted
+func Benchmark_GC(b *testing.B) {
+	const size = 1_000_000
+	hasher := test_util.CheapIntHasher[int]()
+
+	b.Run(fmt.Sprintf("large.Map(%v)", size), func(t *testing.B) {
+		col, err := large.NewMap[int, string](size, hasher)
+		require.NoError(t, err)
+		for i := range size {
+			_, err := col.Set(i, fmt.Sprint(i))
+			require.NoError(t, err)
+		}
+
+		reportGC(t)
+		runtime.KeepAlive(col)
+	})
+
+	b.Run(fmt.Sprintf("maps.Bucketted(%v)", size), func(t *testing.B) {
+		col, err := maps.NewBuckettedMap[int, string](size, hasher)
+		require.NoError(t, err)
+		for i := range size {
+			col.Set(i, fmt.Sprint(i))
+		}
+
+		reportGC(t)
+		runtime.KeepAlive(col)
+	})
+}
+
+func reportGC(b *testing.B) {
+	b.ResetTimer()
+	total := time.Duration(0)
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		runtime.GC()
+		total += time.Since(start)
+	}
+
+	b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "gc-ns/op")
+}
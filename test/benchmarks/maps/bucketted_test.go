@@ -137,6 +137,50 @@ func Benchmark_BuckettedMap_Get(t *testing.B) {
 
 				benchmarks.ReportAdd(t, size)
 			})
+
+			t.Run("Parallel", func(t *testing.B) {
+				t.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						item := items[i%len(items)]
+						v, ok := col.Get(item.GetKey())
+						if !ok || v.Key != item.GetKey() {
+							t.Fail()
+						}
+						i++
+					}
+				})
+			})
+
+			t.Run("Parallel/Writes", func(t *testing.B) {
+				// A single writer keeps updating the items, readers should not be blocked by it
+				done := make(chan struct{})
+				go func() {
+					for {
+						for _, item := range items {
+							select {
+							case <-done:
+								return
+							default:
+							}
+							col.Set(item.GetKey(), item.GetValue())
+						}
+					}
+				}()
+				defer close(done)
+
+				t.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						item := items[i%len(items)]
+						v, ok := col.Get(item.GetKey())
+						if !ok || v.Key != item.GetKey() {
+							t.Fail()
+						}
+						i++
+					}
+				})
+			})
 		})
 	})
 }
@@ -0,0 +1,28 @@
+package maps_test
+
+import (
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+func Benchmark_Counter_Add(t *testing.B) {
+	c, err := maps.NewCounter[int](1000, test_util.CheapIntHasher[int]())
+	require.NoError(t, err)
+	for i := range 1000 {
+		c.Add(i, 1)
+	}
+
+	// Existing counters are changed in place, so this should not allocate
+	t.ReportAllocs()
+	t.ResetTimer()
+	t.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Add(i%1000, 1)
+			i++
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package maps_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/test/benchmarks"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/daanv2/go-optimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Benchmark_Map_Fixed_Get(b *testing.B) {
+	sizes := []uint64{100, 200, 500, 1000, uint64(optimal.SliceSize[maps.KeyValue[int, string]]())}
+	hasher := test_util.CheapIntHasher[int]()
+
+	test_util.Case1(sizes, func(size uint64) {
+		items := make([]maps.KeyValue[int, string], 0, size)
+		misses := make([]maps.KeyValue[int, string], 0, size)
+		for _, item := range test_util.Generate(int(size)) {
+			items = append(items, maps.NewKeyValue(hasher.Hash(item.ID), item.ID, item.Data))
+
+			id := item.ID + int(size)
+			misses = append(misses, maps.NewKey[int, string](hasher.Hash(id), id))
+		}
+
+		// Leave some room, a full bucket can never terminate a lookup early
+		col := maps.NewFixed[int, string](size + size/4)
+		for _, item := range items {
+			ok := col.Set(item)
+			require.True(b, ok)
+		}
+
+		b.Run(fmt.Sprintf("Get(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, item := range items {
+					v, ok := col.Get(item)
+					if !ok || v.Value == "" {
+						t.Fail()
+					}
+				}
+			}
+
+			benchmarks.ReportAdd(t, size)
+		})
+
+		b.Run(fmt.Sprintf("Miss(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, item := range misses {
+					_, ok := col.Get(item)
+					if ok {
+						t.Fail()
+					}
+				}
+			}
+
+			benchmarks.ReportAdd(t, size)
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package maps_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/daanv2/go-cache/maps"
+	"github.com/daanv2/go-cache/pkg/options"
+	"github.com/daanv2/go-cache/sets"
+	test_util "github.com/daanv2/go-cache/test/util"
+	"github.com/stretchr/testify/require"
+)
+
+type stringHasher struct{}
+
+func (stringHasher) Hash(item string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := range len(item) {
+		h = (h ^ uint64(item[i])) * 1099511628211
+	}
+
+	return h
+}
+
+// Benchmark_GC compares the time a garbage collection takes with a filled map, with and without pointer free storage
+func Benchmark_GC(b *testing.B) {
+	const size = 1_000_000
+	modes := map[string][]options.Option[maps.Options]{
+		"Default":     nil,
+		"PointerFree": {maps.WithPointerFree()},
+	}
+
+	for name, opts := range modes {
+		b.Run(fmt.Sprintf("Map/%s(%v)", name, size), func(t *testing.B) {
+			col, err := maps.NewBuckettedMap[string, string](size, stringHasher{}, opts...)
+			require.NoError(t, err)
+			for i := range size {
+				col.Set(fmt.Sprint("key-", i), fmt.Sprint("value-", i))
+			}
+
+			reportGC(t)
+			runtime.KeepAlive(col)
+		})
+	}
+
+	setModes := map[string][]options.Option[sets.Options]{
+		"Default":     nil,
+		"PointerFree": {sets.WithPointerFree()},
+	}
+
+	for name, opts := range setModes {
+		b.Run(fmt.Sprintf("Set/%s(%v)", name, size), func(t *testing.B) {
+			col, err := sets.NewBuckettedSet[string](size, stringHasher{}, opts...)
+			require.NoError(t, err)
+			for i := range size {
+				col.GetOrAdd(fmt.Sprint("key-", i))
+			}
+
+			reportGC(t)
+			runtime.KeepAlive(col)
+		})
+	}
+}
+
+func Benchmark_Map_PointerFree_Get(b *testing.B) {
+	sizes := []uint64{10_000, 100_000}
+
+	test_util.Case1(sizes, func(size uint64) {
+		col, err := maps.NewBuckettedMap[string, string](size, stringHasher{}, maps.WithPointerFree())
+		require.NoError(b, err)
+
+		keys := make([]string, 0, size)
+		for i := range size {
+			keys = append(keys, fmt.Sprint("key-", i))
+			col.Set(keys[i], fmt.Sprint("value-", i))
+		}
+
+		b.Run(fmt.Sprintf("Get(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, key := range keys {
+					if _, ok := col.Get(key); !ok {
+						t.Fail()
+					}
+				}
+			}
+		})
+	})
+}
+
+func reportGC(b *testing.B) {
+	b.ResetTimer()
+	total := time.Duration(0)
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		runtime.GC()
+		total += time.Since(start)
+	}
+
+	b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "gc-ns/op")
+}
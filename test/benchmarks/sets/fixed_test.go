@@ -21,7 +21,13 @@ func Benchmark_Set_Fixed_Get(b *testing.B) {
 			items = append(items, sets.NewSetItem(hasher.Hash(item.ID), item))
 		}
 
-		col := sets.NewFixed[*test_util.TestItem](size)
+		misses := make([]sets.SetItem[*test_util.TestItem], 0, size)
+		for _, item := range test_util.Generate(int(size) * 2)[size:] {
+			misses = append(misses, sets.NewSetItem(hasher.Hash(item.ID), item))
+		}
+
+		// Leave some room, a full bucket can never terminate a lookup early
+		col := sets.NewFixed[*test_util.TestItem](size + size/4)
 
 		for _, item := range items {
 			ok := col.Set(item)
@@ -46,5 +52,18 @@ func Benchmark_Set_Fixed_Get(b *testing.B) {
 
 			benchmarks.ReportAdd(t, size)
 		})
+
+		b.Run(fmt.Sprintf("Miss(%v)", size), func(t *testing.B) {
+			for i := 0; i < t.N; i++ {
+				for _, item := range misses {
+					_, ok := col.Get(item)
+					if ok {
+						t.Fail()
+					}
+				}
+			}
+
+			benchmarks.ReportAdd(t, size)
+		})
 	})
 }
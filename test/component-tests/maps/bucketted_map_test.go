@@ -2,7 +2,10 @@ package large_test
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/daanv2/go-cache/maps"
 	"github.com/daanv2/go-cache/pkg/collections"
@@ -141,3 +144,105 @@ func Test_BuckettedMap_Debug(t *testing.T) {
 		}
 	})
 }
+
+func Test_BuckettedMap_Delete(t *testing.T) {
+	sizes := []uint64{100, 1000}
+
+	test_util.Case1(sizes, func(size uint64) {
+		col, err := maps.NewBuckettedMap[int, string](size, hash.IntegerHasher[int](hash.MD5))
+		require.NoError(t, err)
+
+		items := test_util.Generate(int(size))
+		collections.Shuffle(items)
+
+		t.Run(fmt.Sprintf("Size(%v)", size), func(t *testing.T) {
+			for _, item := range items {
+				col.Set(item.ID, item.Data)
+			}
+
+			for _, item := range items {
+				v, ok := col.Delete(item.ID)
+				require.True(t, ok, item.ID)
+				require.Equal(t, v.GetValue(), item.Data)
+
+				_, ok = col.Get(item.ID)
+				require.False(t, ok, item.ID)
+
+				_, ok = col.Delete(item.ID)
+				require.False(t, ok, item.ID)
+			}
+
+			for range col.Read() {
+				t.Fatal("expected the map to be empty")
+			}
+		})
+	})
+}
+
+func Test_BuckettedMap_ReadWrite_Stress(t *testing.T) {
+	size := 1000
+	col, err := maps.NewBuckettedMap[int, string](uint64(size), test_util.CheapIntHasher[int](), maps.WithBucketAmount(10))
+	require.NoError(t, err)
+
+	items := test_util.Generate(size)
+	for _, item := range items {
+		col.Set(item.ID, item.Data)
+	}
+
+	wg := sync.WaitGroup{}
+	done := make(chan struct{})
+
+	// Writers keep replacing the values and deleting / re-adding some of the keys
+	for w := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for round := 0; ; round++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				for i := w; i < len(items); i += 4 {
+					item := items[i]
+					if round%5 == 0 && item.ID%10 == 0 {
+						col.Delete(item.ID)
+					}
+					col.Set(item.ID, fmt.Sprintf("%s/%d", item.Data, round))
+				}
+			}
+		}()
+	}
+
+	// Readers never see a value of another key, and always find the keys that are not deleted
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 50 {
+				for _, item := range items {
+					v, ok := col.Get(item.ID)
+					if !ok {
+						if item.ID%10 != 0 {
+							t.Errorf("key %v went missing", item.ID)
+						}
+						continue
+					}
+
+					if v.Key != item.ID || (v.Value != item.Data && !strings.HasPrefix(v.Value, item.Data+"/")) {
+						t.Errorf("key %v returned %v", item.ID, v)
+					}
+				}
+			}
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(done)
+	wg.Wait()
+}